@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const auditListLimit = 20
+
+type AuditResult struct {
+	Path         string
+	PolicyCost   int
+	Total        int
+	Costs        map[int]int
+	BelowPolicy  []int
+	InvalidLines []int
+}
+
+func auditHashes(path string, policyCost int) (AuditResult, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return AuditResult{}, err
+	}
+	defer f.Close()
+
+	result := AuditResult{
+		Path:       path,
+		PolicyCost: policyCost,
+		Costs:      make(map[int]int),
+	}
+
+	scanner := bufio.NewScanner(f)
+	line := 0
+	for scanner.Scan() {
+		line++
+		hash := strings.TrimSpace(scanner.Text())
+		if hash == "" || strings.HasPrefix(hash, "#") {
+			continue
+		}
+
+		cost, err := bcrypt.Cost([]byte(hash))
+		if err != nil {
+			result.InvalidLines = append(result.InvalidLines, line)
+			continue
+		}
+
+		result.Total++
+		result.Costs[cost]++
+		if cost < policyCost {
+			result.BelowPolicy = append(result.BelowPolicy, line)
+		}
+	}
+
+	return result, scanner.Err()
+}
+
+func writeAuditReport(out io.Writer, result AuditResult) {
+	fmt.Fprintln(out, "Hash Audit")
+	fmt.Fprintln(out, "----------")
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "File:\t%s\n", result.Path)
+	fmt.Fprintf(w, "Hashes:\t%d\n", result.Total)
+	fmt.Fprintf(w, "Invalid Lines:\t%d\n", len(result.InvalidLines))
+	fmt.Fprintf(w, "Policy Minimum Cost:\t%d\n", result.PolicyCost)
+	w.Flush()
+
+	if result.Total == 0 {
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "  No valid bcrypt hashes found")
+		return
+	}
+
+	costs := make([]int, 0, len(result.Costs))
+	maxCount := 0
+	for cost, count := range result.Costs {
+		costs = append(costs, cost)
+		maxCount = max(maxCount, count)
+	}
+	slices.Sort(costs)
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Cost Distribution")
+	fmt.Fprintln(out, "-----------------")
+	fmt.Fprintln(out)
+
+	w = tabwriter.NewWriter(out, 0, 0, 3, ' ', tabwriter.TabIndent)
+	fmt.Fprintln(w, "Cost\tCount\tShare\t\t")
+	fmt.Fprintln(w, "----\t-----\t-----\t\t")
+	for _, cost := range costs {
+		count := result.Costs[cost]
+		bar := strings.Repeat("█", max(1, count*40/maxCount))
+		fmt.Fprintf(w, "%d\t%d\t%.1f%%\t%s\t\n",
+			cost, count, float64(count)/float64(result.Total)*100, bar)
+	}
+	w.Flush()
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Rehash Plan")
+	fmt.Fprintln(out, "-----------")
+
+	if len(result.BelowPolicy) == 0 {
+		fmt.Fprintf(out, "  All hashes meet the policy minimum cost of %d\n", result.PolicyCost)
+	} else {
+		fmt.Fprintf(out, "  %d of %d hashes (%.1f%%) are below cost %d and should be rehashed on next login\n",
+			len(result.BelowPolicy), result.Total,
+			float64(len(result.BelowPolicy))/float64(result.Total)*100, result.PolicyCost)
+		fmt.Fprintf(out, "  Lines: %s\n", formatLineList(result.BelowPolicy))
+	}
+
+	if len(result.InvalidLines) > 0 {
+		fmt.Fprintf(out, "  Skipped %d lines that are not bcrypt hashes: %s\n",
+			len(result.InvalidLines), formatLineList(result.InvalidLines))
+	}
+}
+
+func formatLineList(lines []int) string {
+	parts := make([]string, 0, min(len(lines), auditListLimit))
+	for _, line := range lines[:min(len(lines), auditListLimit)] {
+		parts = append(parts, fmt.Sprint(line))
+	}
+
+	list := strings.Join(parts, ", ")
+	if len(lines) > auditListLimit {
+		list += fmt.Sprintf(" (and %d more)", len(lines)-auditListLimit)
+	}
+	return list
+}
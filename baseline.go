@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/eldad/bcryptbenchmark/bench"
+)
+
+func loadBaseline(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var baseline Report
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, fmt.Errorf("%s is not a JSON results file: %w", path, err)
+	}
+	return &baseline, nil
+}
+
+func appendBaselineColumn(header []string, rows [][]string, results, baseline []bench.CostResult) ([]string, [][]string) {
+	means := make(map[int]bench.CostResult, len(baseline))
+	for _, b := range baseline {
+		means[b.Cost] = b
+	}
+
+	header = append(header, "vs Baseline")
+	for i, r := range results {
+		change := "n/a"
+		if b, ok := means[r.Cost]; ok && b.Mean > 0 {
+			change = fmt.Sprintf("%+.1f%%", float64(r.Mean-b.Mean)/float64(b.Mean)*100)
+		}
+		rows[i] = append(rows[i], change)
+	}
+
+	return header, rows
+}
+
+func writeBaselineNotes(out io.Writer, results, baselineResults []bench.CostResult) {
+	run := make(map[int]bool, len(results))
+	for _, r := range results {
+		run[r.Cost] = true
+	}
+	inBaseline := make(map[int]bool, len(baselineResults))
+	for _, b := range baselineResults {
+		inBaseline[b.Cost] = true
+	}
+
+	var missingFromRun, missingFromBaseline []int
+	for _, b := range baselineResults {
+		if !run[b.Cost] {
+			missingFromRun = append(missingFromRun, b.Cost)
+		}
+	}
+	for _, r := range results {
+		if !inBaseline[r.Cost] {
+			missingFromBaseline = append(missingFromBaseline, r.Cost)
+		}
+	}
+	slices.Sort(missingFromRun)
+
+	if len(missingFromRun) > 0 || len(missingFromBaseline) > 0 {
+		fmt.Fprintln(out)
+	}
+	if len(missingFromRun) > 0 {
+		fmt.Fprintf(out, "  In the baseline but not benchmarked in this run: cost %s\n", joinInts(missingFromRun))
+	}
+	if len(missingFromBaseline) > 0 {
+		fmt.Fprintf(out, "  Not in the baseline: cost %s\n", joinInts(missingFromBaseline))
+	}
+}
@@ -0,0 +1,105 @@
+package bench
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	AlgoBcrypt   = "bcrypt"
+	AlgoScrypt   = "scrypt"
+	AlgoArgon2id = "argon2id"
+)
+
+// Work parameters held fixed while cost varies. For scrypt, cost is log2 of
+// N; for argon2id it is log2 of the memory in KiB, with one pass and one lane.
+// Both double the work per cost increment, as bcrypt does.
+const (
+	ScryptR       = 8
+	ScryptP       = 1
+	Argon2Time    = 1
+	Argon2Threads = 1
+
+	saltLength = 16
+	keyLength  = 32
+)
+
+var errMismatch = errors.New("password does not match hash")
+
+type hasher struct {
+	hash    func(password []byte, cost int) ([]byte, error)
+	compare func(hash, password []byte, cost int) error
+	// inspect, if set, reads the version and cost a hash embeds; only
+	// bcrypt hashes carry them.
+	inspect func(hash []byte) (version string, cost int, err error)
+}
+
+func (cfg Config) hasher() (hasher, error) {
+	switch cfg.Algorithm {
+	case "", AlgoBcrypt:
+		return hasher{
+			hash: func(password []byte, cost int) ([]byte, error) {
+				return HashPassword(password, cost)
+			},
+			compare: func(hash, password []byte, _ int) error {
+				return ComparePassword(hash, password)
+			},
+			inspect: inspectBcrypt,
+		}, nil
+	case AlgoScrypt:
+		return saltedHasher(func(password, salt []byte, cost int) ([]byte, error) {
+			return scrypt.Key(password, salt, 1<<cost, ScryptR, ScryptP, keyLength)
+		}), nil
+	case AlgoArgon2id:
+		return saltedHasher(func(password, salt []byte, cost int) ([]byte, error) {
+			return argon2.IDKey(password, salt, Argon2Time, 1<<cost, Argon2Threads, keyLength), nil
+		}), nil
+	}
+	return hasher{}, fmt.Errorf("unknown algorithm %q", cfg.Algorithm)
+}
+
+// inspectBcrypt splits "$2a$12$..." into its version, 2a, and its cost.
+func inspectBcrypt(hash []byte) (string, int, error) {
+	cost, err := bcrypt.Cost(hash)
+	if err != nil {
+		return "", 0, err
+	}
+	version, _, _ := strings.Cut(strings.TrimPrefix(string(hash), "$"), "$")
+	return version, cost, nil
+}
+
+// saltedHasher wraps a key derivation function. The hash it produces is the
+// random salt followed by the derived key, and compare re-derives the key,
+// which costs the same as hashing.
+func saltedHasher(derive func(password, salt []byte, cost int) ([]byte, error)) hasher {
+	return hasher{
+		hash: func(password []byte, cost int) ([]byte, error) {
+			salt := make([]byte, saltLength)
+			if _, err := rand.Read(salt); err != nil {
+				return nil, err
+			}
+			key, err := derive(password, salt, cost)
+			if err != nil {
+				return nil, err
+			}
+			return append(salt, key...), nil
+		},
+		compare: func(hash, password []byte, cost int) error {
+			key, err := derive(password, hash[:saltLength], cost)
+			if err != nil {
+				return err
+			}
+			if subtle.ConstantTimeCompare(key, hash[saltLength:]) != 1 {
+				return errMismatch
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,48 @@
+package bench
+
+import "runtime"
+
+// allocCounter accumulates heap allocations per cost across the timed
+// iterations of every pass.
+type allocCounter map[int]*allocTotals
+
+type allocTotals struct {
+	bytes, allocs uint64
+	ops           int
+}
+
+// start reads the memory statistics before an iteration, if cfg asks for
+// them. The returned function reads them again and charges the difference
+// to cost, spread over the ops hashes the iteration ran. Allocations by
+// anything else running at the time are charged too, which is negligible
+// next to a hash.
+func (c allocCounter) start(cfg Config, cost int) func(ops int) {
+	if !cfg.MeasureAllocs {
+		return func(int) {}
+	}
+
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+	return func(ops int) {
+		var after runtime.MemStats
+		runtime.ReadMemStats(&after)
+
+		t := c[cost]
+		if t == nil {
+			t = &allocTotals{}
+			c[cost] = t
+		}
+		t.bytes += after.TotalAlloc - before.TotalAlloc
+		t.allocs += after.Mallocs - before.Mallocs
+		t.ops += ops
+	}
+}
+
+func (c allocCounter) fill(results []CostResult) {
+	for i := range results {
+		if t := c[results[i].Cost]; t != nil && t.ops > 0 {
+			results[i].BytesPerOp = t.bytes / uint64(t.ops)
+			results[i].AllocsPerOp = t.allocs / uint64(t.ops)
+		}
+	}
+}
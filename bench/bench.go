@@ -0,0 +1,571 @@
+// Package bench measures how long bcrypt takes to hash and verify passwords
+// across a range of cost factors, with scrypt and argon2id available on the
+// same cost axis for comparison.
+package bench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// HashPassword and ComparePassword are the single bcrypt entry points for
+// every benchmark, so that an alternative bcrypt implementation only has to
+// be wired in here.
+var (
+	HashPassword    = bcrypt.GenerateFromPassword
+	ComparePassword = bcrypt.CompareHashAndPassword
+)
+
+type Config struct {
+	// Algorithm is AlgoBcrypt, AlgoScrypt or AlgoArgon2id; empty means
+	// bcrypt.
+	Algorithm string `json:"algorithm"`
+	StartCost int    `json:"start_cost"`
+	EndCost   int    `json:"end_cost"`
+	// CostList, if not empty, replaces the StartCost to EndCost range and
+	// Step with exactly these costs, visited in this order.
+	CostList   []int `json:"cost_list,omitempty"`
+	Step       int   `json:"step"`
+	Iterations int   `json:"iterations"`
+	Warmup     int   `json:"warmup"`
+	// Concurrency is the number of hashes run in parallel per iteration. A
+	// value below 1 is treated as 1.
+	Concurrency int           `json:"concurrency"`
+	Budget      time.Duration `json:"budget_ns"`
+	// MinSamples and MaxSamples, if set, clamp the number of samples a
+	// Budget collects at each cost. A concurrent batch is never split, so
+	// MaxSamples can be overshot by less than Concurrency.
+	MinSamples int           `json:"min_samples,omitempty"`
+	MaxSamples int           `json:"max_samples,omitempty"`
+	Target     time.Duration `json:"target_ns"`
+	// Trim is the percentage of samples dropped from each end of the sorted
+	// durations before Mean and StdDev are computed.
+	Trim float64 `json:"trim_percent"`
+	// Repeat runs the whole sweep this many times and merges the samples
+	// of every pass per cost. Zero means once.
+	Repeat int `json:"repeat"`
+	// AutoCV, if above zero, keeps sampling each cost past Iterations until
+	// the coefficient of variation (stddev/mean) of its samples falls below
+	// this percentage, or AutoMax iterations have run.
+	AutoCV  float64 `json:"auto_cv_percent,omitempty"`
+	AutoMax int     `json:"auto_max_iterations,omitempty"`
+	// MeasureAllocs reads runtime.MemStats around every timed iteration to
+	// fill in BytesPerOp and AllocsPerOp. Each read stops the world, which
+	// adds to the wall-clock time but not to the hash latencies.
+	MeasureAllocs bool `json:"measure_allocs"`
+	// Percentiles lists the percentiles reported in CostResult.Percentiles;
+	// empty means DefaultPercentiles.
+	Percentiles []float64 `json:"percentiles,omitempty"`
+	// Interleave takes one iteration of every cost per round instead of all
+	// iterations of one cost before the next, within each pass. Every cost
+	// of a pass is sampled before Target is checked, so it only prunes later
+	// passes.
+	Interleave bool `json:"interleave"`
+	// HashTimeout, if set, abandons any single hash or verification that
+	// runs longer. Its cost and every higher one are then skipped, and the
+	// sweep returns a *TimeoutError along with the other results.
+	HashTimeout time.Duration `json:"hash_timeout_ns,omitempty"`
+
+	// OnProgress, if set, is called before every hash the benchmark times
+	// or runs as warmup. It is always called from the benchmark goroutine.
+	OnProgress func(Progress) `json:"-"`
+	// OnVisit, if set, is called with the samples of every completed visit
+	// to a cost, from the benchmark goroutine.
+	OnVisit func(Visit) `json:"-"`
+}
+
+type CostResult struct {
+	Cost       int             `json:"cost"`
+	Durations  []time.Duration `json:"durations_ns"`
+	Mean       time.Duration   `json:"mean_ns"`
+	StdDev     time.Duration   `json:"stddev_ns"`
+	MeanMargin time.Duration   `json:"mean_margin_ns"`
+	Min        time.Duration   `json:"min_ns"`
+	Max        time.Duration   `json:"max_ns"`
+	// P50 is always filled in, since the ratings and recommendation use the
+	// median whether or not Config.Percentiles lists it.
+	P50 time.Duration `json:"p50_ns"`
+	// Percentiles holds the percentiles requested in Config.Percentiles, in
+	// that order.
+	Percentiles []PercentileValue `json:"percentiles"`
+	Iterations  int               `json:"iterations"`
+	Trimmed     int               `json:"trimmed"`
+	Throughput  float64           `json:"throughput"`
+	// HashVersion and EmbeddedCost are read back from one hash produced at
+	// this cost, e.g. 2a and 12 from "$2a$12$...". They are only set for
+	// bcrypt, whose hashes embed them.
+	HashVersion  string `json:"hash_version,omitempty"`
+	EmbeddedCost int    `json:"embedded_cost,omitempty"`
+	// BytesPerOp and AllocsPerOp are the heap bytes and allocations per
+	// hash, averaged over every sample; only set with MeasureAllocs.
+	BytesPerOp  uint64 `json:"bytes_per_op,omitempty"`
+	AllocsPerOp uint64 `json:"allocs_per_op,omitempty"`
+	// Cached is set by callers that load the result from a cache instead of
+	// measuring it.
+	Cached bool `json:"cached,omitempty"`
+}
+
+type Phase int
+
+const (
+	PhaseWarmup Phase = iota
+	PhaseHash
+	PhasePrepare
+	PhaseVerify
+)
+
+type Progress struct {
+	Phase     Phase
+	Pass      int
+	Cost      int
+	Iteration int
+	// Measured is the time spent in timed iterations at this cost so far.
+	Measured time.Duration
+	// Remaining is the projected time left in the sweep; it is only valid
+	// once Estimated is true, after the first cost completes.
+	Remaining time.Duration
+	Estimated bool
+}
+
+// Visit holds the samples of one pass over one cost. Phase is PhaseHash or
+// PhaseVerify.
+type Visit struct {
+	Phase     Phase
+	Pass      int
+	Cost      int
+	Durations []time.Duration
+}
+
+// Run times the hash function of cfg.Algorithm at every cost in
+// cfg.Costs(). If ctx is cancelled, Run stops at the next iteration boundary
+// and returns the costs completed so far together with ctx.Err().
+func Run(ctx context.Context, cfg Config, password []byte) ([]CostResult, error) {
+	cfg.Concurrency = max(cfg.Concurrency, 1)
+	h, err := cfg.hasher()
+	if err != nil {
+		return nil, err
+	}
+	h = h.withTimeout(cfg.HashTimeout)
+
+	hashes := make(map[int][]byte)
+	allocs := make(allocCounter)
+	results, err := sweep(ctx, cfg, PhaseHash, sampler{
+		prepare: func(cost int, eta *etaEstimator) error {
+			prepareStart := time.Now()
+			for iter := 1; iter <= cfg.Warmup && ctx.Err() == nil; iter++ {
+				cfg.report(eta.progress(PhaseWarmup, cost, iter, 0, time.Since(prepareStart)))
+				if _, _, _, err := runConcurrent(h, password, cost, cfg.Concurrency); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		sample: func(cost int) ([]time.Duration, time.Duration, error) {
+			stop := allocs.start(cfg, cost)
+			batch, wall, hash, err := runConcurrent(h, password, cost, cfg.Concurrency)
+			stop(len(batch))
+			if err != nil {
+				return nil, 0, err
+			}
+			hashes[cost] = hash
+			return batch, wall, nil
+		},
+	})
+
+	allocs.fill(results)
+	if inspectErr := inspectHashes(h, results, hashes); inspectErr != nil {
+		return results, inspectErr
+	}
+	return results, err
+}
+
+// RunVerify times verification against one hash generated per cost, with the
+// same sampling, concurrency, cancellation and target rules as Run.
+func RunVerify(ctx context.Context, cfg Config, password []byte) ([]CostResult, error) {
+	cfg.Concurrency = max(cfg.Concurrency, 1)
+	h, err := cfg.hasher()
+	if err != nil {
+		return nil, err
+	}
+	h = h.withTimeout(cfg.HashTimeout)
+
+	hashes := make(map[int][]byte)
+	allocs := make(allocCounter)
+	results, err := sweep(ctx, cfg, PhaseVerify, sampler{
+		prepare: func(cost int, eta *etaEstimator) error {
+			prepareStart := time.Now()
+			cfg.report(eta.progress(PhasePrepare, cost, 0, 0, 0))
+
+			// The extra hash generated for each cost is not part of the
+			// projection; it is about one iteration and keeps the ETA simple.
+			hash, err := h.hash(password, cost)
+			if err != nil {
+				return fmt.Errorf("generating hash at cost %d: %w", cost, err)
+			}
+			hashes[cost] = hash
+
+			for iter := 1; iter <= cfg.Warmup && ctx.Err() == nil; iter++ {
+				cfg.report(eta.progress(PhaseWarmup, cost, iter, 0, time.Since(prepareStart)))
+				if _, _, err := verifyConcurrent(h, hash, password, cost, cfg.Concurrency); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		sample: func(cost int) ([]time.Duration, time.Duration, error) {
+			stop := allocs.start(cfg, cost)
+			batch, wall, err := verifyConcurrent(h, hashes[cost], password, cost, cfg.Concurrency)
+			stop(len(batch))
+			if err != nil {
+				return nil, 0, err
+			}
+			return batch, wall, nil
+		},
+	})
+
+	allocs.fill(results)
+	if inspectErr := inspectHashes(h, results, hashes); inspectErr != nil {
+		return results, inspectErr
+	}
+	return results, err
+}
+
+// inspectHashes fills in the version and cost embedded in the last hash
+// produced at each cost, so the caller can check the library honored the
+// cost it was asked for.
+func inspectHashes(h hasher, results []CostResult, hashes map[int][]byte) error {
+	if h.inspect == nil {
+		return nil
+	}
+	for i := range results {
+		hash, ok := hashes[results[i].Cost]
+		if !ok {
+			continue
+		}
+		version, cost, err := h.inspect(hash)
+		if err != nil {
+			return fmt.Errorf("inspecting hash at cost %d: %w", results[i].Cost, err)
+		}
+		results[i].HashVersion, results[i].EmbeddedCost = version, cost
+	}
+	return nil
+}
+
+// sampler measures one operation. prepare runs once per cost and pass before
+// any sampling, for warmup and setup; sample runs one timed iteration and
+// returns its latencies and wall-clock time.
+type sampler struct {
+	prepare func(cost int, eta *etaEstimator) error
+	sample  func(cost int) ([]time.Duration, time.Duration, error)
+}
+
+// visit is the sampling state of one cost during one pass.
+type visit struct {
+	start     time.Time
+	iter      int
+	durations []time.Duration
+	wall      time.Duration
+}
+
+// sweep visits every cost Repeat times, pass after pass, and merges the
+// samples of all visits to a cost before computing its statistics. A visit
+// cut short by an interrupt is dropped rather than reported with fewer
+// samples than requested; earlier visits to the same cost are kept. A visit
+// cut short by a hash timeout is dropped too, along with every sample at
+// that cost and above.
+func sweep(ctx context.Context, cfg Config, phase Phase, s sampler) ([]CostResult, error) {
+	costs := cfg.Costs()
+	durations := make([][]time.Duration, len(costs))
+	walls := make([]time.Duration, len(costs))
+	skip := make([]bool, len(costs))
+	cut := make([]bool, len(costs))
+	eta := etaEstimator{cfg: cfg}
+
+	// cutoff handles a hash timeout at cost. The costs above it would
+	// take even longer, so they are cut for the rest of the sweep.
+	var timeout *TimeoutError
+	cutoff := func(cost int, err error) bool {
+		if !errors.Is(err, errHashTimeout) {
+			return false
+		}
+		if timeout == nil || cost < timeout.Cost {
+			timeout = &TimeoutError{Phase: phase, Cost: cost, Timeout: cfg.HashTimeout}
+		}
+		for j := range costs {
+			if costs[j] >= cost {
+				skip[j], cut[j] = true, true
+				durations[j], walls[j] = nil, 0
+			}
+		}
+		return true
+	}
+
+	collect := func() []CostResult {
+		results := make([]CostResult, 0, len(costs))
+		for i, cost := range costs {
+			if len(durations[i]) == 0 {
+				continue
+			}
+			stats := CalculateTrimmedStats(cost, durations[i], cfg.Trim)
+			if len(cfg.Percentiles) > 0 {
+				stats.Percentiles = CalculatePercentiles(durations[i], cfg.Percentiles)
+			}
+			stats.Throughput = float64(len(durations[i])) / walls[i].Seconds()
+			results = append(results, stats)
+		}
+		return results
+	}
+
+	// step runs one iteration at costs[i] if its visit still needs samples,
+	// and reports whether it did.
+	step := func(i int, v *visit) (bool, error) {
+		if !cfg.keepSampling(v.iter+1, v.wall, v.durations) || ctx.Err() != nil {
+			return false, nil
+		}
+		v.iter++
+		cfg.report(eta.progress(phase, costs[i], v.iter, v.wall, time.Since(v.start)))
+
+		batch, wall, err := s.sample(costs[i])
+		if cutoff(costs[i], err) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		v.durations = append(v.durations, batch...)
+		v.wall += wall
+		return true, nil
+	}
+
+	// finish merges a completed visit into the results.
+	finish := func(pass, i int, v *visit) {
+		durations[i] = append(durations[i], v.durations...)
+		walls[i] += v.wall
+		if cfg.OnVisit != nil {
+			cfg.OnVisit(Visit{Phase: phase, Pass: pass, Cost: costs[i], Durations: v.durations})
+		}
+
+		// Hashing time grows with cost, so once the target is exceeded no
+		// higher cost can be closer to it from below. Later passes only
+		// revisit the costs the first pass kept.
+		if pass == 1 && cfg.Target > 0 && CalculateStats(costs[i], v.durations).P50 > cfg.Target {
+			for j := i + 1; j < len(costs); j++ {
+				skip[j] = skip[j] || costs[j] >= costs[i]
+			}
+		}
+	}
+
+	for pass := 1; pass <= cfg.passes() && ctx.Err() == nil; pass++ {
+		eta.pass = pass
+
+		if cfg.Interleave {
+			// Round-robin: prepare every cost, then take one iteration of
+			// each per round, so that drift over the run such as thermal
+			// throttling spreads evenly across all costs.
+			visits := make([]*visit, len(costs))
+			for i, cost := range costs {
+				if skip[i] || ctx.Err() != nil {
+					continue
+				}
+				visits[i] = &visit{start: time.Now()}
+				if err := s.prepare(cost, &eta); cutoff(cost, err) {
+					continue
+				} else if err != nil {
+					return collect(), err
+				}
+			}
+
+			for sampled := true; sampled && ctx.Err() == nil; {
+				sampled = false
+				for i, v := range visits {
+					if v == nil || cut[i] {
+						continue
+					}
+					ran, err := step(i, v)
+					if err != nil {
+						return collect(), err
+					}
+					if ran {
+						eta.complete(costs[i], v.wall/time.Duration(v.iter))
+					}
+					sampled = sampled || ran
+				}
+			}
+			if ctx.Err() != nil {
+				break
+			}
+
+			for i, v := range visits {
+				if v != nil && !cut[i] {
+					finish(pass, i, v)
+				}
+			}
+			continue
+		}
+
+		for i, cost := range costs {
+			if ctx.Err() != nil {
+				break
+			}
+			if skip[i] {
+				continue
+			}
+
+			v := &visit{start: time.Now()}
+			if err := s.prepare(cost, &eta); cutoff(cost, err) {
+				continue
+			} else if err != nil {
+				return collect(), err
+			}
+			for {
+				ran, err := step(i, v)
+				if err != nil {
+					return collect(), err
+				}
+				if !ran {
+					break
+				}
+			}
+			if ctx.Err() != nil {
+				break
+			}
+			if v.iter == 0 || cut[i] {
+				continue
+			}
+
+			eta.complete(cost, v.wall/time.Duration(v.iter))
+			finish(pass, i, v)
+		}
+	}
+
+	if timeout != nil {
+		return collect(), errors.Join(ctx.Err(), timeout)
+	}
+	return collect(), ctx.Err()
+}
+
+// RunConcurrent hashes password with bcrypt concurrency times in parallel and
+// returns the latency of each hash along with the wall-clock time of the
+// whole batch. Each latency runs from the start of the batch, as for
+// requests that arrive together, so time spent waiting for a CPU counts. A
+// concurrency below 1 is treated as 1.
+func RunConcurrent(password []byte, cost, concurrency int) ([]time.Duration, time.Duration, error) {
+	h, _ := Config{}.hasher()
+	durations, wall, _, err := runConcurrent(h, password, cost, max(concurrency, 1))
+	return durations, wall, err
+}
+
+// runConcurrent also returns one of the hashes it produced.
+func runConcurrent(h hasher, password []byte, cost, concurrency int) ([]time.Duration, time.Duration, []byte, error) {
+	durations := make([]time.Duration, concurrency)
+	hashes := make([][]byte, concurrency)
+	errs := make([]error, concurrency)
+
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			hashes[i], errs[i] = h.hash(password, cost)
+			durations[i] = time.Since(start)
+		}()
+	}
+	wg.Wait()
+	wall := time.Since(start)
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, 0, nil, fmt.Errorf("generating hash at cost %d: %w", cost, err)
+		}
+	}
+
+	return durations, wall, hashes[0], nil
+}
+
+// verifyConcurrent is runConcurrent for verification: it compares password
+// against hash concurrency times in parallel, timing each from the start of
+// the batch.
+func verifyConcurrent(h hasher, hash, password []byte, cost, concurrency int) ([]time.Duration, time.Duration, error) {
+	durations := make([]time.Duration, concurrency)
+	errs := make([]error, concurrency)
+
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = h.compare(hash, password, cost)
+			durations[i] = time.Since(start)
+		}()
+	}
+	wg.Wait()
+	wall := time.Since(start)
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, 0, fmt.Errorf("verifying hash at cost %d: %w", cost, err)
+		}
+	}
+
+	return durations, wall, nil
+}
+
+// Costs returns the cost levels a sweep visits: CostList if set, otherwise
+// StartCost, then every Step after it, always ending on EndCost even when
+// the last step overshoots it. A Step below 1 is treated as 1.
+func (cfg Config) Costs() []int {
+	if len(cfg.CostList) > 0 {
+		return cfg.CostList
+	}
+
+	step := max(cfg.Step, 1)
+
+	var costs []int
+	for cost := cfg.StartCost; cost < cfg.EndCost; cost += step {
+		costs = append(costs, cost)
+	}
+	if cfg.StartCost <= cfg.EndCost {
+		costs = append(costs, cfg.EndCost)
+	}
+	return costs
+}
+
+func (cfg Config) passes() int {
+	return max(cfg.Repeat, 1)
+}
+
+// keepSampling reports whether another iteration should run at the current
+// cost. Iterations is a minimum; with a budget, sampling continues until the
+// time spent at this cost exceeds it, within MinSamples and MaxSamples, and
+// with AutoCV until the samples taken so far are stable enough.
+func (cfg Config) keepSampling(iter int, elapsed time.Duration, durations []time.Duration) bool {
+	if cfg.Budget > 0 && cfg.MaxSamples > 0 && len(durations) >= cfg.MaxSamples {
+		return false
+	}
+	if iter <= cfg.Iterations {
+		return true
+	}
+	if cfg.AutoCV > 0 {
+		return iter <= cfg.AutoMax && !Stable(CalculateTrimmedStats(0, durations, cfg.Trim), cfg.AutoCV)
+	}
+	return cfg.Budget > 0 && (elapsed < cfg.Budget || len(durations) < cfg.MinSamples)
+}
+
+// Stable reports whether the coefficient of variation of r is below
+// cvPercent. Fewer than two samples are never stable.
+func Stable(r CostResult, cvPercent float64) bool {
+	return len(r.Durations) >= 2 && r.Mean > 0 && float64(r.StdDev)/float64(r.Mean)*100 < cvPercent
+}
+
+func (cfg Config) report(p Progress) {
+	if cfg.OnProgress != nil {
+		cfg.OnProgress(p)
+	}
+}
@@ -0,0 +1,94 @@
+package bench
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRunSurfacesHashError(t *testing.T) {
+	errStub := errors.New("stub failure")
+	original := HashPassword
+	HashPassword = func([]byte, int) ([]byte, error) { return nil, errStub }
+	t.Cleanup(func() { HashPassword = original })
+
+	for _, warmup := range []int{0, 1} {
+		cfg := Config{StartCost: 4, EndCost: 4, Iterations: 1, Warmup: warmup, Concurrency: 1}
+		results, err := Run(context.Background(), cfg, []byte("password"))
+		if !errors.Is(err, errStub) {
+			t.Fatalf("warmup %d: Run error = %v, want it to wrap %v", warmup, err, errStub)
+		}
+		if !strings.Contains(err.Error(), "cost 4") {
+			t.Errorf("warmup %d: Run error %q does not name the cost", warmup, err)
+		}
+		if len(results) != 0 {
+			t.Errorf("warmup %d: Run returned %d results, want none", warmup, len(results))
+		}
+	}
+}
+
+func TestInterleavePreservesSampleCounts(t *testing.T) {
+	base := Config{
+		CostList:    []int{4, 5, 6},
+		Iterations:  3,
+		Repeat:      2,
+		Warmup:      1,
+		Concurrency: 2,
+	}
+	interleaved := base
+	interleaved.Interleave = true
+
+	sequential, err := Run(context.Background(), base, []byte("password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	roundRobin, err := Run(context.Background(), interleaved, []byte("password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(roundRobin) != len(sequential) {
+		t.Fatalf("interleaved run returned %d costs, sequential %d", len(roundRobin), len(sequential))
+	}
+	// Iterations times Concurrency per pass, merged over Repeat passes.
+	const want = 3 * 2 * 2
+	for i := range sequential {
+		s, r := sequential[i], roundRobin[i]
+		if s.Cost != r.Cost {
+			t.Errorf("result %d: sequential cost %d, interleaved cost %d", i, s.Cost, r.Cost)
+		}
+		if s.Iterations != want || r.Iterations != want {
+			t.Errorf("cost %d: sequential %d samples, interleaved %d, want %d", s.Cost, s.Iterations, r.Iterations, want)
+		}
+	}
+}
+
+func TestZeroConcurrencyRunsOneHash(t *testing.T) {
+	results, err := Run(context.Background(), Config{StartCost: 4, EndCost: 4, Iterations: 1}, []byte("password"))
+	if err != nil {
+		t.Fatalf("Run with zero Concurrency: %v", err)
+	}
+	if len(results) != 1 || len(results[0].Durations) != 1 {
+		t.Errorf("Run with zero Concurrency = %+v, want one cost with one sample", results)
+	}
+
+	durations, _, err := RunConcurrent([]byte("password"), 4, 0)
+	if err != nil {
+		t.Fatalf("RunConcurrent with zero concurrency: %v", err)
+	}
+	if len(durations) != 1 {
+		t.Errorf("RunConcurrent with zero concurrency returned %d durations, want 1", len(durations))
+	}
+}
+
+func TestRunVerifyTakesConcurrencySamplesPerIteration(t *testing.T) {
+	cfg := Config{StartCost: 4, EndCost: 4, Iterations: 2, Concurrency: 4, Repeat: 2}
+	results, err := RunVerify(context.Background(), cfg, []byte("password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || len(results[0].Durations) != 16 {
+		t.Errorf("RunVerify = %+v, want one cost with 16 samples", results)
+	}
+}
@@ -0,0 +1,123 @@
+package bench
+
+import (
+	"math"
+	"slices"
+	"time"
+)
+
+type etaEstimator struct {
+	cfg          Config
+	pass         int
+	lastCost     int
+	perIteration time.Duration
+	known        bool
+}
+
+func (e *etaEstimator) complete(cost int, perIteration time.Duration) {
+	e.lastCost = cost
+	e.perIteration = perIteration
+	e.known = true
+}
+
+// progress builds a Progress update, projecting the time left in the sweep
+// from the last completed cost on the assumption that each cost increment
+// doubles the time per iteration. Passes still to come count as full
+// sweeps. elapsed is the time already spent at the cost currently running.
+func (e *etaEstimator) progress(phase Phase, cost, iter int, measured, elapsed time.Duration) Progress {
+	p := Progress{
+		Phase:     phase,
+		Pass:      e.pass,
+		Cost:      cost,
+		Iteration: iter,
+		Measured:  measured,
+		Estimated: e.known,
+	}
+	if !e.known {
+		return p
+	}
+
+	costs := e.cfg.Costs()
+	if e.cfg.Interleave {
+		p.Remaining = e.interleavedRemaining(costs, iter)
+		return p
+	}
+	current := slices.Index(costs, cost)
+
+	var total time.Duration
+	for i, c := range costs {
+		costTime := projectCostTime(e.cfg, scaleByCost(e.perIteration, c-e.lastCost))
+		if i >= current {
+			total += costTime
+		}
+		total += costTime * time.Duration(e.cfg.passes()-e.pass)
+	}
+	p.Remaining = max(total-elapsed, 0)
+
+	return p
+}
+
+// interleavedRemaining projects the time left when every round takes one
+// iteration of each cost: the rounds left in this pass, counting the current
+// one in full, then every later pass.
+func (e *etaEstimator) interleavedRemaining(costs []int, iter int) time.Duration {
+	var round, pass time.Duration
+	for _, c := range costs {
+		perIteration := scaleByCost(e.perIteration, c-e.lastCost)
+		round += perIteration
+		pass += projectCostTime(e.cfg, perIteration)
+	}
+	rounds := max(e.cfg.Iterations-iter+1, 1)
+	return round*time.Duration(rounds) + pass*time.Duration(e.cfg.passes()-e.pass)
+}
+
+// Estimate times one batch of hashes at StartCost and projects the wall-clock
+// time of the whole sweep from it, warmup and every pass included, with the
+// same doubling heuristic as the progress ETA. It covers a hashing sweep; a
+// verify sweep takes about as long.
+func Estimate(cfg Config, password []byte) (time.Duration, error) {
+	h, err := cfg.hasher()
+	if err != nil {
+		return 0, err
+	}
+
+	_, perIteration, _, err := runConcurrent(h, password, cfg.StartCost, max(cfg.Concurrency, 1))
+	if err != nil {
+		return 0, err
+	}
+
+	var total time.Duration
+	for _, c := range cfg.Costs() {
+		total += projectCostTime(cfg, scaleByCost(perIteration, c-cfg.StartCost))
+	}
+	return total * time.Duration(cfg.passes()), nil
+}
+
+// scaleByCost doubles d for every cost increment; later passes also scale
+// down to lower costs, where increments is negative.
+func scaleByCost(d time.Duration, increments int) time.Duration {
+	return time.Duration(math.Ldexp(float64(d), increments))
+}
+
+// projectCostTime estimates the wall-clock time of one cost level, warmup
+// included, given the time of a single iteration at that cost.
+func projectCostTime(cfg Config, perIteration time.Duration) time.Duration {
+	measured := perIteration * time.Duration(cfg.Iterations)
+	if cfg.Budget > 0 {
+		measured = max(measured, cfg.Budget)
+
+		// The sample bounds count hashes; each iteration runs a batch of
+		// Concurrency of them.
+		batches := func(samples int) time.Duration {
+			concurrency := max(cfg.Concurrency, 1)
+			return time.Duration((samples + concurrency - 1) / concurrency)
+		}
+		if cfg.MinSamples > 0 {
+			measured = max(measured, perIteration*batches(cfg.MinSamples))
+		}
+		if cfg.MaxSamples > 0 {
+			measured = min(measured, perIteration*batches(cfg.MaxSamples))
+		}
+	}
+	return measured + perIteration*time.Duration(cfg.Warmup)
+}
@@ -0,0 +1,167 @@
+package bench
+
+import (
+	"math"
+	"slices"
+	"time"
+)
+
+// DefaultPercentiles are the percentiles CostResult.Percentiles holds unless
+// Config.Percentiles says otherwise.
+var DefaultPercentiles = []float64{25, 50, 75, 95, 99}
+
+type PercentileValue struct {
+	Percentile float64       `json:"percentile"`
+	Value      time.Duration `json:"value_ns"`
+}
+
+// CalculatePercentiles returns each of percentiles over durations, which
+// need not be sorted.
+func CalculatePercentiles(durations []time.Duration, percentiles []float64) []PercentileValue {
+	sorted := slices.Clone(durations)
+	slices.Sort(sorted)
+
+	values := make([]PercentileValue, len(percentiles))
+	for i, p := range percentiles {
+		values[i] = PercentileValue{Percentile: p, Value: CalculatePercentile(sorted, p)}
+	}
+	return values
+}
+
+// CalculateStats summarizes the samples taken at one cost without trimming.
+// An empty slice gives a result with every statistic zero.
+func CalculateStats(cost int, durations []time.Duration) CostResult {
+	return CalculateTrimmedStats(cost, durations, 0)
+}
+
+// CalculateTrimmedStats drops trimPercent of the samples from each end of the
+// sorted durations before computing Mean and StdDev, so that a stray GC pause
+// does not dominate them. Min, Max and the percentiles always use every
+// sample. At least one sample is kept however large trimPercent is.
+func CalculateTrimmedStats(cost int, durations []time.Duration, trimPercent float64) CostResult {
+	sorted := make([]time.Duration, len(durations))
+	copy(sorted, durations)
+	slices.Sort(sorted)
+
+	trim := int(float64(len(sorted)) * trimPercent / 100)
+	if len(sorted) > 0 {
+		trim = min(trim, (len(sorted)-1)/2)
+	}
+	kept := sorted[trim : len(sorted)-trim]
+
+	mean := CalculateMean(kept)
+	stdDev := CalculateStdDev(kept, mean)
+	margin := CalculateMeanMargin(stdDev, len(kept))
+
+	var minimum, maximum time.Duration
+	if len(sorted) > 0 {
+		minimum, maximum = sorted[0], sorted[len(sorted)-1]
+	}
+
+	return CostResult{
+		Cost:        cost,
+		Durations:   durations,
+		Mean:        mean,
+		StdDev:      stdDev,
+		MeanMargin:  margin,
+		Min:         minimum,
+		Max:         maximum,
+		P50:         CalculatePercentile(sorted, 50),
+		Iterations:  len(durations),
+		Trimmed:     2 * trim,
+		Percentiles: CalculatePercentiles(sorted, DefaultPercentiles),
+	}
+}
+
+// CalculateMean returns the arithmetic mean, truncated to whole nanoseconds,
+// or 0 for an empty slice.
+func CalculateMean(durations []time.Duration) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+
+	var total time.Duration
+	for _, d := range durations {
+		total += d
+	}
+	return total / time.Duration(len(durations))
+}
+
+// CalculateStdDev returns the sample standard deviation, dividing by n-1.
+// Fewer than two samples have no spread, so it returns 0 for them.
+func CalculateStdDev(durations []time.Duration, mean time.Duration) time.Duration {
+	if len(durations) < 2 {
+		return 0
+	}
+
+	var sumSquares float64
+	for _, d := range durations {
+		diff := float64(d - mean)
+		sumSquares += diff * diff
+	}
+
+	variance := sumSquares / float64(len(durations)-1)
+	return time.Duration(math.Sqrt(variance))
+}
+
+// tCritical95 holds the two-sided 95% critical values of Student's t
+// distribution for 1 to 30 degrees of freedom.
+var tCritical95 = [...]float64{
+	12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+	2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+	2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042,
+}
+
+// CalculateMeanMargin returns the half-width of the 95% confidence interval
+// for the mean of n samples with the given standard deviation. It uses the t
+// distribution up to 30 degrees of freedom and the normal approximation
+// beyond. Fewer than two samples have no interval, so it returns 0 for them.
+func CalculateMeanMargin(stdDev time.Duration, n int) time.Duration {
+	if n < 2 {
+		return 0
+	}
+
+	critical := 1.96
+	if n-1 <= len(tCritical95) {
+		critical = tCritical95[n-2]
+	}
+	return time.Duration(critical * float64(stdDev) / math.Sqrt(float64(n)))
+}
+
+// MinSamplesForPercentile returns the fewest samples that leave at least one
+// sample beyond the percentile on its far side, e.g. 4 for P25 and P75, 20
+// for P95 and 100 for P99.
+func MinSamplesForPercentile(percentile float64) int {
+	tail := min(percentile, 100-percentile)
+	if tail <= 0 {
+		return 1
+	}
+	// The epsilon keeps 100/0.1 from rounding up past 1000.
+	return int(math.Ceil(100/tail - 1e-9))
+}
+
+// CalculatePercentile interpolates linearly between the two closest ranks of
+// an ascending slice, with rank = percentile/100 * (n-1). For samples 10, 20,
+// 30 and 40, P25 falls at rank 0.75 and gives 17.5. An empty slice gives 0
+// and a single sample is every percentile. With fewer samples than
+// MinSamplesForPercentile the result is only an interpolation toward the
+// extreme: P99 of 10, 20 and 30 is 29.8.
+func CalculatePercentile(sorted []time.Duration, percentile float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+
+	rank := (percentile / 100) * float64(len(sorted)-1)
+	lower := int(rank)
+	upper := lower + 1
+
+	if upper >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+
+	weight := rank - float64(lower)
+	return time.Duration(float64(sorted[lower])*(1-weight) + float64(sorted[upper])*weight)
+}
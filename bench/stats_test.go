@@ -0,0 +1,178 @@
+package bench
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func ms(values ...float64) []time.Duration {
+	durations := make([]time.Duration, len(values))
+	for i, v := range values {
+		durations[i] = time.Duration(v * float64(time.Millisecond))
+	}
+	return durations
+}
+
+func TestCalculateMean(t *testing.T) {
+	tests := []struct {
+		name      string
+		durations []time.Duration
+		want      time.Duration
+	}{
+		{"empty", nil, 0},
+		{"single", ms(42), 42 * time.Millisecond},
+		{"multiple", ms(10, 20, 30, 40), 25 * time.Millisecond},
+		{"truncated", []time.Duration{1, 2}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CalculateMean(tt.durations); got != tt.want {
+				t.Errorf("CalculateMean(%v) = %v, want %v", tt.durations, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateStdDev(t *testing.T) {
+	tests := []struct {
+		name      string
+		durations []time.Duration
+		want      time.Duration
+	}{
+		{"empty", nil, 0},
+		{"single", ms(42), 0},
+		{"identical", ms(5, 5, 5), 0},
+		// Deviations of -1 and +1 ms over n-1 = 1 give a variance of 2 ms².
+		{"pair", ms(1, 3), 1414213 * time.Nanosecond},
+		{"multiple", ms(2, 4, 4, 4, 5, 5, 7, 9), 2138089 * time.Nanosecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CalculateStdDev(tt.durations, CalculateMean(tt.durations))
+			if diff := got - tt.want; diff < -1 || diff > 1 {
+				t.Errorf("CalculateStdDev(%v) = %v, want %v", tt.durations, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculatePercentile(t *testing.T) {
+	tests := []struct {
+		name       string
+		sorted     []time.Duration
+		percentile float64
+		want       time.Duration
+	}{
+		{"empty", nil, 50, 0},
+		{"single", ms(42), 99, 42 * time.Millisecond},
+		{"min", ms(10, 20, 30, 40), 0, 10 * time.Millisecond},
+		{"max", ms(10, 20, 30, 40), 100, 40 * time.Millisecond},
+		// rank = 0.25 * 3 = 0.75, three quarters of the way from 10 to 20.
+		{"p25 interpolated", ms(10, 20, 30, 40), 25, 17500 * time.Microsecond},
+		{"median interpolated", ms(10, 20, 30, 40), 50, 25 * time.Millisecond},
+		{"median exact", ms(10, 20, 30), 50, 20 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CalculatePercentile(tt.sorted, tt.percentile); got != tt.want {
+				t.Errorf("CalculatePercentile(%v, %g) = %v, want %v", tt.sorted, tt.percentile, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmptySamplesDoNotPanic(t *testing.T) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("panic on empty samples: %v", r)
+		}
+	}()
+
+	if got := CalculateMean(nil); got != 0 {
+		t.Errorf("CalculateMean(nil) = %v, want 0", got)
+	}
+
+	r := CalculateTrimmedStats(0, nil, 0)
+	zero := []time.Duration{r.Mean, r.StdDev, r.MeanMargin, r.Min, r.Max, r.P50}
+	for _, d := range zero {
+		if d != 0 {
+			t.Errorf("CalculateTrimmedStats(0, nil, 0) = %+v, want every statistic zero", r)
+			break
+		}
+	}
+	for _, p := range r.Percentiles {
+		if p.Value != 0 {
+			t.Errorf("CalculateTrimmedStats(0, nil, 0) P%g = %v, want 0", p.Percentile, p.Value)
+		}
+	}
+	if r.Iterations != 0 || r.Trimmed != 0 {
+		t.Errorf("CalculateTrimmedStats(0, nil, 0) counts %d iterations and %d trimmed, want 0", r.Iterations, r.Trimmed)
+	}
+}
+
+func TestCalculateMeanMargin(t *testing.T) {
+	const stdDev = 10 * time.Millisecond
+
+	for _, n := range []int{0, 1} {
+		if got := CalculateMeanMargin(stdDev, n); got != 0 {
+			t.Errorf("CalculateMeanMargin(%v, %d) = %v, want 0", stdDev, n, got)
+		}
+	}
+
+	previous := CalculateMeanMargin(stdDev, 2)
+	for n := 3; n <= 200; n++ {
+		got := CalculateMeanMargin(stdDev, n)
+		if got >= previous {
+			t.Errorf("CalculateMeanMargin(%v, %d) = %v, not below %v for n = %d", stdDev, n, got, previous, n-1)
+		}
+		previous = got
+	}
+
+	// n = 31 is the last sample count on the t table (30 degrees of
+	// freedom); n = 32 switches to the normal approximation.
+	tests := []struct {
+		n        int
+		critical float64
+	}{
+		{2, 12.706},
+		{31, 2.042},
+		{32, 1.96},
+		{100, 1.96},
+	}
+	for _, tt := range tests {
+		want := time.Duration(tt.critical * float64(stdDev) / math.Sqrt(float64(tt.n)))
+		if got := CalculateMeanMargin(stdDev, tt.n); got != want {
+			t.Errorf("CalculateMeanMargin(%v, %d) = %v, want %v (critical value %g)", stdDev, tt.n, got, want, tt.critical)
+		}
+	}
+}
+
+func TestCalculatePercentileSmallSamples(t *testing.T) {
+	// rank = 0.99 * 2 = 1.98, so P99 sits 98% of the way from 20 to 30;
+	// pinned so a change to the interpolation is a deliberate one.
+	if got, want := CalculatePercentile(ms(10, 20, 30), 99), 29800*time.Microsecond; got != want {
+		t.Errorf("P99 of 10, 20, 30 ms = %v, want %v", got, want)
+	}
+}
+
+func TestMinSamplesForPercentile(t *testing.T) {
+	tests := []struct {
+		percentile float64
+		want       int
+	}{
+		{25, 4},
+		{75, 4},
+		{95, 20},
+		{99, 100},
+		{99.9, 1000},
+		{50, 2},
+		{0, 1},
+		{100, 1},
+	}
+	for _, tt := range tests {
+		if got := MinSamplesForPercentile(tt.percentile); got != tt.want {
+			t.Errorf("MinSamplesForPercentile(%g) = %d, want %d", tt.percentile, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package bench
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var errHashTimeout = errors.New("hash timed out")
+
+// TimeoutError reports that a hash or verification at Cost ran past
+// Config.HashTimeout. The sweep skipped that cost and every higher one.
+type TimeoutError struct {
+	Phase   Phase
+	Cost    int
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("a hash at cost %d took longer than %s", e.Cost, e.Timeout)
+}
+
+// withTimeout makes hash and compare give up with errHashTimeout after
+// timeout. None of the algorithms can be cancelled mid-hash, so the
+// abandoned call keeps running in its goroutine, holding a CPU and its
+// memory, until it completes on its own. A timeout of zero leaves h as is.
+func (h hasher) withTimeout(timeout time.Duration) hasher {
+	if timeout <= 0 {
+		return h
+	}
+
+	hash, compare := h.hash, h.compare
+	h.hash = func(password []byte, cost int) ([]byte, error) {
+		type result struct {
+			hash []byte
+			err  error
+		}
+		// Buffered, so the goroutine can deliver its result and exit even
+		// after nobody is waiting for it.
+		done := make(chan result, 1)
+		go func() {
+			b, err := hash(password, cost)
+			done <- result{b, err}
+		}()
+
+		select {
+		case r := <-done:
+			return r.hash, r.err
+		case <-time.After(timeout):
+			return nil, errHashTimeout
+		}
+	}
+	h.compare = func(b, password []byte, cost int) error {
+		done := make(chan error, 1)
+		go func() {
+			done <- compare(b, password, cost)
+		}()
+
+		select {
+		case err := <-done:
+			return err
+		case <-time.After(timeout):
+			return errHashTimeout
+		}
+	}
+	return h
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/eldad/bcryptbenchmark/bench"
+)
+
+// resultCache keeps the results of earlier runs in a directory, one JSON file
+// per cost. An entry is only reused on the same machine, for the same
+// algorithm, operation, concurrency, password length and sampling settings,
+// and until it is older than the TTL. A nil *resultCache never hits and
+// stores nothing.
+type resultCache struct {
+	dir         string
+	ttl         time.Duration
+	refresh     bool
+	fingerprint string
+}
+
+type cacheEntry struct {
+	StoredAt time.Time        `json:"stored_at"`
+	Result   bench.CostResult `json:"result"`
+}
+
+func newResultCache(cfg Config, report Report) (*resultCache, error) {
+	if cfg.CacheDir == "" {
+		return nil, nil
+	}
+
+	// The host, runtime and hashing library together stand in for the
+	// machine; a new Go release or GOMAXPROCS setting starts a fresh cache.
+	machine, err := json.Marshal(struct {
+		Host           string
+		Environment    Environment
+		Implementation Implementation
+	}{report.Host, report.Environment, report.Implementation})
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(machine)
+
+	return &resultCache{
+		dir:         cfg.CacheDir,
+		ttl:         cfg.CacheTTL,
+		refresh:     cfg.NoCache,
+		fingerprint: hex.EncodeToString(sum[:8]),
+	}, nil
+}
+
+func (c *resultCache) path(cfg Config, operation string, passwordLength, cost int) string {
+	name := fmt.Sprintf("%s-%s-cost%d-len%d-c%d-s%s-%s.json",
+		cfg.Algorithm, operation, cost, passwordLength, cfg.Concurrency, samplingKey(cfg), c.fingerprint)
+	return filepath.Join(c.dir, name)
+}
+
+// samplingKey hashes the settings that decide how many samples a cost gets
+// and how they are taken, so that raising -iterations, for example, measures
+// afresh instead of reusing two old samples. -trim and -percentiles are left
+// out: they are applied to the stored samples again on load.
+func samplingKey(cfg Config) string {
+	settings, _ := json.Marshal(struct {
+		Iterations, Warmup, MinSamples, MaxSamples, AutoMax, Repeat int
+		Budget, HashTimeout                                         time.Duration
+		AutoCV                                                      float64
+		MeasureAllocs, Interleave                                   bool
+	}{
+		cfg.Iterations, cfg.Warmup, cfg.MinSamples, cfg.MaxSamples, cfg.AutoMax, cfg.Repeat,
+		cfg.Budget, cfg.HashTimeout, cfg.AutoCV, cfg.MeasureAllocs, cfg.Interleave,
+	})
+	sum := sha256.Sum256(settings)
+	return hex.EncodeToString(sum[:4])
+}
+
+// load returns the cached result for cost, recomputed with the current
+// -trim and -percentiles. A missing, unreadable or expired entry is a miss.
+func (c *resultCache) load(cfg Config, operation string, passwordLength, cost int) (bench.CostResult, bool) {
+	if c == nil || c.refresh {
+		return bench.CostResult{}, false
+	}
+
+	data, err := os.ReadFile(c.path(cfg, operation, passwordLength, cost))
+	if err != nil {
+		return bench.CostResult{}, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || len(entry.Result.Durations) == 0 {
+		return bench.CostResult{}, false
+	}
+	if time.Since(entry.StoredAt) > c.ttl {
+		return bench.CostResult{}, false
+	}
+
+	stored := entry.Result
+	r := bench.CalculateTrimmedStats(cost, stored.Durations, cfg.Trim)
+	if len(cfg.Percentiles) > 0 {
+		r.Percentiles = bench.CalculatePercentiles(stored.Durations, cfg.Percentiles)
+	}
+	r.Throughput = stored.Throughput
+	r.HashVersion, r.EmbeddedCost = stored.HashVersion, stored.EmbeddedCost
+	r.BytesPerOp, r.AllocsPerOp = stored.BytesPerOp, stored.AllocsPerOp
+	r.Cached = true
+	return r, true
+}
+
+func (c *resultCache) store(cfg Config, operation string, passwordLength int, r bench.CostResult) error {
+	if c == nil || r.Cached {
+		return nil
+	}
+
+	data, err := json.Marshal(cacheEntry{StoredAt: time.Now().UTC(), Result: r})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(c.path(cfg, operation, passwordLength, r.Cost), data, 0o644)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"io"
+	"os"
+)
+
+const (
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorRed    = "\033[31m"
+	colorReset  = "\033[0m"
+)
+
+// colorEnabled reports whether out is a terminal that should receive ANSI
+// colors. Files, pipes and -output never do, and NO_COLOR (see
+// https://no-color.org) or -no-color turn colors off everywhere.
+func colorEnabled(out io.Writer, noColor bool) bool {
+	return !noColor && os.Getenv("NO_COLOR") == "" && isTerminal(out)
+}
+
+// isTerminal reports whether w is a character device such as a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func colorize(enabled bool, color, s string) string {
+	if !enabled {
+		return s
+	}
+	return color + s + colorReset
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+func csvHeader(cfg Config) []string {
+	header := []string{"cost", "iterations", "mean_ms", "stddev_ms", "min_ms"}
+	for _, p := range reportedPercentiles(cfg) {
+		header = append(header, percentileName(p)+"_ms")
+	}
+	return append(header, "max_ms", "throughput", "run_id", "operation")
+}
+
+func writeCSVFile(path string, report Report) error {
+	if path == "-" {
+		return writeCSV(os.Stdout, report)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := writeCSV(f, report); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func writeCSV(w io.Writer, report Report) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader(report.Config)); err != nil {
+		return err
+	}
+
+	ms := func(d time.Duration) string {
+		return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', -1, 64)
+	}
+	for _, op := range report.operations() {
+		for _, r := range op.Results {
+			row := []string{fmt.Sprint(r.Cost), fmt.Sprint(r.Iterations)}
+			for _, stat := range costStatistics(r) {
+				row = append(row, ms(stat.Value))
+			}
+			row = append(row, strconv.FormatFloat(r.Throughput, 'f', 2, 64), report.RunID, op.Operation)
+			err := cw.Write(row)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+	"unicode/utf8"
+
+	"github.com/eldad/bcryptbenchmark/bench"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const bcryptMaxPasswordBytes = 72
+
+type EncodingResult struct {
+	Strategy   string
+	InputBytes int
+	Rejected   bool
+	Result     bench.CostResult
+}
+
+type encodingStrategy struct {
+	name    string
+	prepare func([]byte) []byte
+}
+
+var encodingStrategies = []encodingStrategy{
+	{"raw", func(p []byte) []byte { return p }},
+	{"utf8-multibyte", toFullwidth},
+	{"sha256-hex", func(p []byte) []byte {
+		sum := sha256.Sum256(p)
+		return []byte(hex.EncodeToString(sum[:]))
+	}},
+}
+
+// toFullwidth maps printable ASCII onto the Unicode fullwidth forms, giving a
+// password with the same characters but three UTF-8 bytes per character. The
+// result is cut at a rune boundary within bcrypt's 72-byte limit, so that the
+// multibyte input is always timed rather than rejected.
+func toFullwidth(p []byte) []byte {
+	out := make([]byte, 0, bcryptMaxPasswordBytes)
+	for _, b := range p {
+		r := rune(b)
+		if b > ' ' && b < 0x7f {
+			r = r - '!' + '！'
+		}
+		if len(out)+utf8.RuneLen(r) > bcryptMaxPasswordBytes {
+			break
+		}
+		out = utf8.AppendRune(out, r)
+	}
+	return out
+}
+
+func runEncodings(cfg Config, password []byte, progress io.Writer) ([]EncodingResult, error) {
+	results := make([]EncodingResult, 0, len(encodingStrategies))
+	spinnerIdx := 0
+
+	for _, strategy := range encodingStrategies {
+		input := strategy.prepare(password)
+		result := EncodingResult{Strategy: strategy.name, InputBytes: len(input)}
+
+		durations := make([]time.Duration, 0, cfg.Iterations)
+		for iter := 1; iter <= cfg.Iterations; iter++ {
+			spinnerIdx = (spinnerIdx + 1) % len(spinnerFrames)
+			fmt.Fprintf(progress, "\r\033[K%s Running: encoding=%s, cost=%d, iteration=%d/%d",
+				spinnerFrames[spinnerIdx], strategy.name, cfg.StartCost, iter, cfg.Iterations)
+
+			start := time.Now()
+			_, err := bench.HashPassword(input, cfg.StartCost)
+			if errors.Is(err, bcrypt.ErrPasswordTooLong) {
+				result.Rejected = true
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			durations = append(durations, time.Since(start))
+		}
+
+		if !result.Rejected {
+			result.Result = bench.CalculateStats(cfg.StartCost, durations)
+		}
+		results = append(results, result)
+	}
+
+	fmt.Fprint(progress, "\r\033[K")
+
+	return results, nil
+}
+
+func writeEncodingsReport(out io.Writer, cfg Config, results []EncodingResult) {
+	title := fmt.Sprintf("Password Encodings (cost %d)", cfg.StartCost)
+	fmt.Fprintln(out, title)
+	fmt.Fprintln(out, underline(title))
+	fmt.Fprintln(out)
+
+	var raw time.Duration
+	for _, r := range results {
+		if r.Strategy == "raw" && !r.Rejected {
+			raw = r.Result.Mean
+		}
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', tabwriter.TabIndent)
+	fmt.Fprintln(w, "Strategy\tInput Bytes\tEffective Bytes\tMean\tStdDev\tvs Raw\t")
+	fmt.Fprintln(w, "--------\t-----------\t---------------\t----\t------\t------\t")
+	for _, r := range results {
+		if r.Rejected {
+			fmt.Fprintf(w, "%s\t%d\t-\trejected\t-\t-\t\n", r.Strategy, r.InputBytes)
+			continue
+		}
+
+		change := "-"
+		if raw > 0 {
+			change = fmt.Sprintf("%+.1f%%", float64(r.Result.Mean-raw)/float64(raw)*100)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\t\n",
+			r.Strategy, r.InputBytes, min(r.InputBytes, bcryptMaxPasswordBytes),
+			formatDuration(r.Result.Mean), formatDuration(r.Result.StdDev), change)
+	}
+	w.Flush()
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "  bcrypt's work is fixed by the cost, so timing should not depend on the encoding.")
+	fmt.Fprintf(out, "  Inputs over %d bytes are rejected by golang.org/x/crypto/bcrypt (other libraries truncate them).\n",
+		bcryptMaxPasswordBytes)
+	fmt.Fprintln(out, "  Pre-hashing with SHA-256 to hex yields 64 bytes for a password of any length,")
+	fmt.Fprintln(out, "  so every character contributes to the hash without hitting the limit.")
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/eldad/bcryptbenchmark/bench"
+)
+
+// checkFailOver returns an error naming every cost, or only cfg.FailCost if
+// set, whose mean exceeds cfg.FailOver. It runs after the report is written,
+// so CI logs keep the data behind a failure.
+func checkFailOver(cfg Config, report Report) error {
+	var over []string
+	check := func(label string, results []bench.CostResult) {
+		for _, r := range results {
+			if cfg.FailCost != 0 && r.Cost != cfg.FailCost {
+				continue
+			}
+			if r.Mean > cfg.FailOver {
+				over = append(over, fmt.Sprintf("%scost %d (mean %s)", label, r.Cost, formatDuration(r.Mean)))
+			}
+		}
+	}
+	check("", report.Results)
+	check("verify ", report.VerifyResults)
+
+	if len(over) > 0 {
+		return &exitError{code: exitFailOver, err: fmt.Errorf("Mean latency above the -fail-over limit of %s: %s",
+			formatDuration(cfg.FailOver), strings.Join(over, ", "))}
+	}
+	measured := slices.ContainsFunc(report.Results, func(r bench.CostResult) bool { return r.Cost == cfg.FailCost })
+	if cfg.FailCost != 0 && !measured {
+		return fmt.Errorf("Cost %d given to -fail-cost was not measured", cfg.FailCost)
+	}
+	return nil
+}
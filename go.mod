@@ -2,4 +2,9 @@ module github.com/eldad/bcryptbenchmark
 
 go 1.25.6
 
-require golang.org/x/crypto v0.47.0
+require (
+	golang.org/x/crypto v0.47.0
+	golang.org/x/term v0.39.0
+)
+
+require golang.org/x/sys v0.40.0 // indirect
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// grafanaRecord is one flat row of the grafana format, shaped for Grafana's
+// JSON and Infinity datasources.
+type grafanaRecord struct {
+	// Operation is hash or verify, following -mode.
+	Operation string `json:"operation"`
+	// Cost is the bcrypt cost factor the metric was measured at.
+	Cost int `json:"cost"`
+	// Metric is one of mean, stddev, min, p25, p50, p75, p95, p99, max or
+	// iterations, with the percentiles following -percentiles.
+	Metric string `json:"metric"`
+	// Value is in seconds for duration metrics and a count for iterations.
+	Value float64 `json:"value"`
+	// Timestamp is the run start time in RFC 3339 format, UTC.
+	Timestamp string `json:"timestamp"`
+	// Host is the hostname of the machine that ran the benchmark.
+	Host string `json:"host"`
+	// RunID is shared by every record and output of the same run.
+	RunID string `json:"run_id"`
+}
+
+func grafanaRecords(report Report) []grafanaRecord {
+	timestamp := report.Timestamp.Format(time.RFC3339)
+	records := make([]grafanaRecord, 0, (len(report.Results)+len(report.VerifyResults))*10)
+
+	for _, op := range report.operations() {
+		for _, r := range op.Results {
+			for _, stat := range costStatistics(r) {
+				records = append(records, grafanaRecord{
+					Operation: op.Operation,
+					Cost:      r.Cost,
+					Metric:    stat.Name,
+					Value:     stat.Value.Seconds(),
+					Timestamp: timestamp,
+					Host:      report.Host,
+					RunID:     report.RunID,
+				})
+			}
+			records = append(records, grafanaRecord{
+				Operation: op.Operation,
+				Cost:      r.Cost,
+				Metric:    "iterations",
+				Value:     float64(r.Iterations),
+				Timestamp: timestamp,
+				Host:      report.Host,
+				RunID:     report.RunID,
+			})
+		}
+	}
+
+	return records
+}
+
+func writeGrafana(w io.Writer, report Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(grafanaRecords(report))
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"strings"
+
+	"github.com/eldad/bcryptbenchmark/bench"
+)
+
+const bcryptModule = "golang.org/x/crypto"
+
+type Implementation struct {
+	Library   string `json:"library"`
+	Version   string `json:"version"`
+	BuildTags string `json:"build_tags"`
+	Platform  string `json:"platform"`
+}
+
+// detectImplementation reads the library version and build tags from the
+// build metadata of the running binary, so runs of binaries built with
+// different tags or versions can be told apart. It does not probe the
+// Blowfish code path: golang.org/x/crypto only has the pure-Go one. scrypt
+// and argon2id come from the same module, so only the package path changes
+// for them.
+func detectImplementation(algo string) Implementation {
+	impl := Implementation{
+		Library:   bcryptModule + "/" + algo,
+		Version:   "unknown",
+		BuildTags: "none",
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if algo == bench.AlgoArgon2id {
+		impl.Library = bcryptModule + "/argon2"
+	}
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return impl
+	}
+
+	for _, dep := range info.Deps {
+		if dep.Path == bcryptModule {
+			impl.Version = dep.Version
+			if dep.Replace != nil {
+				impl.Version = fmt.Sprintf("%s => %s %s", dep.Version, dep.Replace.Path, dep.Replace.Version)
+			}
+		}
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "-tags" && setting.Value != "" {
+			impl.BuildTags = setting.Value
+		}
+	}
+
+	return impl
+}
+
+func (impl Implementation) String() string {
+	return fmt.Sprintf("%s %s (%s)", impl.Library, impl.Version, impl.Platform)
+}
+
+// writeEmbeddedCostCheck confirms that the one hash inspected per cost embeds
+// the cost it was requested at, which catches a bcrypt package that clamps
+// or ignores the cost it is given.
+func writeEmbeddedCostCheck(out io.Writer, label string, results []bench.CostResult) {
+	var versions []string
+	mismatches := 0
+	for _, r := range results {
+		if r.EmbeddedCost == 0 {
+			continue
+		}
+		if !slices.Contains(versions, r.HashVersion) {
+			versions = append(versions, r.HashVersion)
+		}
+		if r.EmbeddedCost != r.Cost {
+			mismatches++
+			fmt.Fprintf(out, "  Warning: %scost %d produced a $%s$%02d$ hash; the library did not use the requested cost\n",
+				label, r.Cost, r.HashVersion, r.EmbeddedCost)
+		}
+	}
+	if len(versions) > 0 && mismatches == 0 {
+		fmt.Fprintf(out, "  Embedded cost: every inspected %shash ($%s$) embeds its requested cost\n",
+			label, strings.Join(versions, "$, $"))
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/eldad/bcryptbenchmark/bench"
+	"golang.org/x/term"
+)
+
+const (
+	histogramBins       = 10
+	histogramMinSamples = 10
+)
+
+// terminalWidth asks the terminal on stdout for its size. Shells rarely
+// export COLUMNS to child processes, so it is only a fallback for when stdout
+// is not a terminal.
+func terminalWidth() int {
+	if n, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && n > 0 {
+		return n
+	}
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	return 80
+}
+
+// histogramCounts buckets durations into bins of equal width between lo and
+// hi; hi itself falls into the last bin.
+func histogramCounts(durations []time.Duration, lo, hi time.Duration, bins int) []int {
+	counts := make([]int, bins)
+	width := float64(hi-lo) / float64(bins)
+	for _, d := range durations {
+		bin := 0
+		if width > 0 {
+			bin = min(int(float64(d-lo)/width), bins-1)
+		}
+		counts[bin]++
+	}
+	return counts
+}
+
+func writeHistograms(out io.Writer, title string, results []bench.CostResult, width int) {
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, title)
+	fmt.Fprintln(out, underline(title))
+
+	for _, r := range results {
+		fmt.Fprintln(out)
+		fmt.Fprintf(out, "Cost %d (%d samples)\n", r.Cost, len(r.Durations))
+		if len(r.Durations) < histogramMinSamples {
+			fmt.Fprintf(out, "  Note: fewer than %d samples; raise -iterations for a meaningful shape\n", histogramMinSamples)
+		}
+
+		if r.Min == r.Max {
+			fmt.Fprintf(out, "  Every sample took %s\n", formatDuration(r.Min))
+			continue
+		}
+
+		counts := histogramCounts(r.Durations, r.Min, r.Max, histogramBins)
+		binWidth := (r.Max - r.Min) / histogramBins
+
+		labels := make([]string, len(counts))
+		labelWidth, peak := 0, 0
+		for i, c := range counts {
+			lo := r.Min + binWidth*time.Duration(i)
+			hi := lo + binWidth
+			if i == len(counts)-1 {
+				hi = r.Max
+			}
+			labels[i] = formatDuration(lo) + " - " + formatDuration(hi)
+			labelWidth = max(labelWidth, utf8.RuneCountInString(labels[i]))
+			peak = max(peak, c)
+		}
+
+		// Two spaces of indent, the label, two spaces, the bar, a space and
+		// the count fill the line.
+		countWidth := len(strconv.Itoa(peak))
+		barWidth := max(width-labelWidth-countWidth-5, 10)
+
+		for i, c := range counts {
+			label := labels[i] + strings.Repeat(" ", labelWidth-utf8.RuneCountInString(labels[i]))
+			bar := strings.Repeat("#", c*barWidth/max(peak, 1))
+			fmt.Fprintf(out, "  %s  %-*s %*d\n", label, barWidth, bar, countWidth, c)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/eldad/bcryptbenchmark/bench"
+)
+
+// historyRecord is one line of the -log file. Durations are in nanoseconds,
+// as in the JSON report.
+type historyRecord struct {
+	RunID       string        `json:"run_id"`
+	Timestamp   time.Time     `json:"timestamp"`
+	Host        string        `json:"host"`
+	Config      Config        `json:"config"`
+	Environment Environment   `json:"environment"`
+	Means       []historyMean `json:"means"`
+	VerifyMeans []historyMean `json:"verify_means,omitempty"`
+	Interrupted bool          `json:"interrupted"`
+}
+
+type historyMean struct {
+	Cost       int           `json:"cost"`
+	Mean       time.Duration `json:"mean_ns"`
+	Iterations int           `json:"iterations"`
+}
+
+func historyMeans(results []bench.CostResult) []historyMean {
+	means := make([]historyMean, len(results))
+	for i, r := range results {
+		means[i] = historyMean{Cost: r.Cost, Mean: r.Mean, Iterations: r.Iterations}
+	}
+	return means
+}
+
+// appendHistory appends the run to path as one JSON line, creating the file
+// if needed. The line goes out in a single O_APPEND write, so on a local
+// filesystem lines from concurrent runs do not interleave.
+func appendHistory(path string, report Report) error {
+	line, err := json.Marshal(historyRecord{
+		RunID:       report.RunID,
+		Timestamp:   report.Timestamp,
+		Host:        report.Host,
+		Config:      report.Config,
+		Environment: report.Environment,
+		Means:       historyMeans(report.Results),
+		VerifyMeans: historyMeans(report.VerifyResults),
+		Interrupted: report.Interrupted,
+	})
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
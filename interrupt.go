@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+)
+
+// notifyInterrupt returns a context cancelled by the first Ctrl-C, so the
+// benchmark loops can stop at the next iteration boundary and still report
+// what they measured. A hash in flight cannot be cancelled, so a second
+// Ctrl-C falls back to the default handler and kills the process.
+func notifyInterrupt(progress io.Writer, plain bool) context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+
+	go func() {
+		<-signals
+		signal.Stop(signals)
+		if plain {
+			fmt.Fprintln(progress, "Interrupted, finishing the current hash (Ctrl-C again to quit)")
+		} else {
+			fmt.Fprint(progress, "\r\033[KInterrupted, finishing the current hash (Ctrl-C again to quit)")
+		}
+		cancel()
+	}()
+
+	return ctx
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/eldad/bcryptbenchmark/bench"
+)
+
+type KneeStep struct {
+	Concurrency int
+	Mean        time.Duration
+	Throughput  float64
+	Slowdown    float64
+}
+
+func runKnee(cfg Config, password []byte, progress io.Writer) ([]KneeStep, int, error) {
+	var steps []KneeStep
+	var baseline time.Duration
+	knee := 0
+	spinnerIdx := 0
+
+	for concurrency := 1; concurrency <= cfg.KneeMax; concurrency *= 2 {
+		durations := make([]time.Duration, 0, concurrency*cfg.Iterations)
+		var wall time.Duration
+
+		for iter := 1; iter <= cfg.Iterations; iter++ {
+			spinnerIdx = (spinnerIdx + 1) % len(spinnerFrames)
+			fmt.Fprintf(progress, "\r\033[K%s Running: cost=%d, concurrency=%d, iteration=%d/%d",
+				spinnerFrames[spinnerIdx], cfg.StartCost, concurrency, iter, cfg.Iterations)
+
+			batch, batchWall, err := bench.RunConcurrent(password, cfg.StartCost, concurrency)
+			if err != nil {
+				return nil, 0, err
+			}
+			durations = append(durations, batch...)
+			wall += batchWall
+		}
+
+		mean := bench.CalculateMean(durations)
+		if concurrency == 1 {
+			baseline = mean
+		}
+
+		step := KneeStep{
+			Concurrency: concurrency,
+			Mean:        mean,
+			Throughput:  float64(len(durations)) / wall.Seconds(),
+			Slowdown:    float64(mean-baseline) / float64(baseline) * 100,
+		}
+		steps = append(steps, step)
+
+		if step.Slowdown > cfg.KneeThreshold {
+			break
+		}
+		knee = concurrency
+	}
+
+	fmt.Fprint(progress, "\r\033[K")
+
+	return steps, knee, nil
+}
+
+func writeKneeReport(out io.Writer, cfg Config, steps []KneeStep, knee int) {
+	title := fmt.Sprintf("Concurrency Knee (cost %d)", cfg.StartCost)
+	fmt.Fprintln(out, title)
+	fmt.Fprintln(out, underline(title))
+	fmt.Fprintln(out)
+
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', tabwriter.TabIndent)
+	fmt.Fprintln(w, "Concurrency\tMean\tThroughput\tSlowdown\t")
+	fmt.Fprintln(w, "-----------\t----\t----------\t--------\t")
+	for _, s := range steps {
+		fmt.Fprintf(w, "%d\t%s\t%.2f/s\t%+.1f%%\t\n",
+			s.Concurrency, formatDuration(s.Mean), s.Throughput, s.Slowdown)
+	}
+	w.Flush()
+
+	fmt.Fprintln(out)
+	for _, s := range steps {
+		if s.Concurrency == knee {
+			fmt.Fprintf(out, "  Knee: %d concurrent hashes (mean %s, %+.1f%% vs single-threaded)\n",
+				knee, formatDuration(s.Mean), s.Slowdown)
+		}
+	}
+
+	last := steps[len(steps)-1]
+	if last.Slowdown > cfg.KneeThreshold {
+		fmt.Fprintf(out, "  Latency degraded beyond %.0f%% at %d concurrent hashes\n", cfg.KneeThreshold, last.Concurrency)
+	} else {
+		fmt.Fprintf(out, "  Latency stayed within %.0f%% up to the %d concurrency cap\n", cfg.KneeThreshold, cfg.KneeMax)
+	}
+}
+
+func underline(title string) string {
+	b := make([]byte, len([]rune(title)))
+	for i := range b {
+		b[i] = '-'
+	}
+	return string(b)
+}
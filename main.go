@@ -1,255 +1,1103 @@
 package main
 
 import (
-	"crypto/rand"
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
-	"math"
+	"maps"
 	"os"
+	"path/filepath"
+	"runtime"
 	"slices"
+	"strconv"
+	"strings"
 	"text/tabwriter"
 	"time"
 
+	"github.com/eldad/bcryptbenchmark/bench"
 	"golang.org/x/crypto/bcrypt"
 )
 
-var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
-
 type Config struct {
-	StartCost      int
-	EndCost        int
-	Password       string
-	GenerateLength int
-	Iterations     int
+	bench.Config
+
+	// Command is the verb the tool was run with: sweep (the default) or
+	// single.
+	Command        string        `json:"command"`
+	Password       string        `json:"-"`
+	Password2      string        `json:"-"`
+	PasswordFile   string        `json:"password_file"`
+	Quiet          bool          `json:"quiet"`
+	Verbose        bool          `json:"verbose"`
+	Progress       string        `json:"-"`
+	GenerateLength int           `json:"generate_length"`
+	MinCostFloor   int           `json:"min_cost_floor"`
+	Format         string        `json:"format"`
+	Knee           bool          `json:"knee"`
+	KneeThreshold  float64       `json:"knee_threshold"`
+	KneeMax        int           `json:"knee_max"`
+	AuditPath      string        `json:"audit_path"`
+	Plain          bool          `json:"plain"`
+	NoColor        bool          `json:"no_color"`
+	Encodings      bool          `json:"encodings"`
+	AllowRoot      bool          `json:"allow_root"`
+	InstanceCost   float64       `json:"instance_cost"`
+	InstanceVCPUs  int           `json:"instance_vcpus"`
+	Mode           string        `json:"mode"`
+	CSVPath        string        `json:"csv_path"`
+	LogPath        string        `json:"log_path"`
+	FailOver       time.Duration `json:"fail_over_ns"`
+	FailCost       int           `json:"fail_cost"`
+	BaselinePath   string        `json:"baseline_path"`
+	MaxProcs       int           `json:"maxprocs"`
+	Seed           *int64        `json:"seed,omitempty"`
+	Histogram      bool          `json:"histogram"`
+	OutputPath     string        `json:"output_path"`
+	PlotPath       string        `json:"plot_path"`
+	PlotLog        bool          `json:"plot_log"`
+	CacheDir       string        `json:"cache_dir"`
+	CacheTTL       time.Duration `json:"cache_ttl_ns"`
+	NoCache        bool          `json:"no_cache"`
+	DryRun         bool          `json:"dry_run"`
+
+	ThresholdFast       time.Duration `json:"threshold_fast_ns"`
+	ThresholdGood       time.Duration `json:"threshold_good_ns"`
+	ThresholdAcceptable time.Duration `json:"threshold_acceptable_ns"`
+	ThresholdSlow       time.Duration `json:"threshold_slow_ns"`
+
+	// PasswordLength and Password2Length are the lengths in bytes before
+	// truncation to bcrypt's 72-byte limit.
+	PasswordLength  int `json:"password_length"`
+	Password2Length int `json:"password2_length,omitempty"`
 }
 
-type CostResult struct {
-	Cost       int
-	Durations  []time.Duration
-	Mean       time.Duration
-	StdDev     time.Duration
-	P25        time.Duration
-	P75        time.Duration
-	P95        time.Duration
-	P99        time.Duration
-	Iterations int
+// Exit codes are part of the command-line interface, documented in the
+// README, so scripts can tell a bad invocation from a failed run.
+const (
+	exitUsage    = 2
+	exitFailure  = 3
+	exitFailOver = 4
+)
+
+// exitError makes main exit with code instead of exitFailure.
+type exitError struct {
+	code int
+	err  error
 }
 
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
 func main() {
-	cfg := parseFlags()
+	err := run()
+	if err == nil {
+		return
+	}
+
+	log.Print(err)
+	code := exitFailure
+	var exit *exitError
+	if errors.As(err, &exit) {
+		code = exit.code
+	}
+	os.Exit(code)
+}
+
+func run() (err error) {
+	// Malformed flags never get here: the flag set prints the usage and
+	// exits with exitUsage itself.
+	cfg, err := parseFlags(os.Args[1:])
+	if err != nil {
+		return &exitError{code: exitUsage, err: err}
+	}
+
+	out := io.Writer(os.Stdout)
+	if cfg.OutputPath != "" {
+		f, createErr := os.Create(cfg.OutputPath)
+		if createErr != nil {
+			return fmt.Errorf("Error creating output file: %w", createErr)
+		}
+		defer func() {
+			if closeErr := f.Close(); closeErr != nil && err == nil {
+				err = fmt.Errorf("Error writing report: %w", closeErr)
+			}
+		}()
+		out = f
+	}
+
+	if cfg.MaxProcs > 0 {
+		runtime.GOMAXPROCS(cfg.MaxProcs)
+	}
+
+	privilege := detectPrivilege()
+	warnIfRoot(privilege, cfg.AllowRoot || cfg.Quiet)
+
+	if cfg.AuditPath != "" {
+		policyCost := cfg.MinCostFloor
+		if policyCost == 0 {
+			policyCost = bcrypt.DefaultCost
+		}
+
+		result, err := auditHashes(cfg.AuditPath, policyCost)
+		if err != nil {
+			return fmt.Errorf("Error reading hashes: %w", err)
+		}
+		writeAuditReport(out, result)
+		return nil
+	}
+
+	var baseline *Report
+	if cfg.BaselinePath != "" {
+		baseline, err = loadBaseline(cfg.BaselinePath)
+		if err != nil {
+			return fmt.Errorf("Error loading baseline: %w", err)
+		}
+	}
+
+	password, err := resolvePassword(cfg)
+	if err != nil {
+		return err
+	}
+	// Encodings mode hashes the password as given, so that an over-long raw
+	// input shows the limit that pre-hashing gets around.
+	untruncated := password
+	cfg.PasswordLength, cfg.Password2Length = len(password), len(cfg.Password2)
+	if cfg.Algorithm == bench.AlgoBcrypt {
+		password = limitPasswordLength(password, cfg.Quiet || cfg.Encodings)
+		cfg.Password2 = string(limitPasswordLength([]byte(cfg.Password2), cfg.Quiet))
+	}
+
+	progress := io.Writer(os.Stdout)
+	switch {
+	case cfg.Quiet:
+		progress = io.Discard
+	case !isHumanFormat(cfg.Format) || cfg.OutputPath != "":
+		progress = os.Stderr
+	}
+	plain := cfg.Progress == progressPlain || (cfg.Progress == progressAuto && !isTerminal(progress))
+	if plain && progress == os.Stdout {
+		// Keep the progress lines out of a redirected report.
+		progress = os.Stderr
+	}
+	if isHumanFormat(cfg.Format) && !cfg.Quiet {
+		fmt.Fprintln(out, "Bcrypt Cost Benchmark")
+		fmt.Fprintln(out, "=====================")
+		fmt.Fprintln(out)
+	}
+
+	if cfg.Knee {
+		steps, knee, err := runKnee(cfg, password, animatedOnly(progress, plain))
+		if err != nil {
+			return fmt.Errorf("\nError generating hash: %w", err)
+		}
+		writeKneeReport(out, cfg, steps, knee)
+		return nil
+	}
+
+	if cfg.Encodings {
+		results, err := runEncodings(cfg, untruncated, animatedOnly(progress, plain))
+		if err != nil {
+			return fmt.Errorf("\nError generating hash: %w", err)
+		}
+		writeEncodingsReport(out, cfg, results)
+		return nil
+	}
+
+	if cfg.DryRun {
+		return writeDryRun(out, cfg, password)
+	}
+
+	ctx := notifyInterrupt(progress, plain)
+
+	spin := &spinner{out: progress, cfg: cfg, plain: plain}
+	cfg.OnProgress = spin.update
+	if cfg.Verbose || plain {
+		cfg.OnVisit = spin.visited
+	}
+
+	report, err := newReport(cfg, privilege)
+	if err != nil {
+		return err
+	}
+	cache, err := newResultCache(cfg, report)
+	if err != nil {
+		return fmt.Errorf("Error opening cache: %w", err)
+	}
+	sweep, operation := bench.Run, "hash"
+	if cfg.Mode == modeCompare {
+		sweep, operation = bench.RunVerify, "verify"
+	}
+	var cutoff *Cutoff
+	if report.Results, cutoff, err = runSweep(ctx, sweep, operation, cfg, password, spin, cache); err != nil {
+		return err
+	}
+	report.Cutoffs = appendCutoff(report.Cutoffs, cutoff)
+	if cfg.Target > 0 && len(report.Results) > 0 && recommendCost(report.Results, cfg.Target, 0).OptimalCost == 0 {
+		cheapest := slices.MinFunc(report.Results, func(a, b bench.CostResult) int { return cmp.Compare(a.Cost, b.Cost) })
+		return fmt.Errorf("Cheapest cost %d already takes %s (median), above the %s target",
+			cheapest.Cost, formatDuration(cheapest.P50), formatDuration(cfg.Target))
+	}
+	if cfg.Mode == modeBoth {
+		if report.VerifyResults, cutoff, err = runSweep(ctx, bench.RunVerify, "verify", cfg, password, spin, cache); err != nil {
+			return err
+		}
+		report.Cutoffs = appendCutoff(report.Cutoffs, cutoff)
+	}
+	if cfg.Password2 != "" {
+		// The cache only knows the password length, so the second password
+		// would reuse the first one's entries; it is always measured.
+		if report.Password2Results, cutoff, err = runSweep(ctx, sweep, operation, cfg, []byte(cfg.Password2), spin, nil); err != nil {
+			return err
+		}
+		report.Cutoffs = appendCutoff(report.Cutoffs, cutoff)
+	}
+	report.Interrupted = ctx.Err() != nil
+
+	switch cfg.Format {
+	case formatGrafana:
+		err = writeGrafana(out, report)
+	case formatJSON:
+		err = writeJSON(out, report)
+	case formatNDJSON:
+		err = writeNDJSON(out, report)
+	case formatStable:
+		err = writeStable(out, report, len(password))
+	case formatMarkdown:
+		err = writeMarkdown(out, report, password, baseline)
+	case formatPrometheus:
+		err = writePrometheus(out, report)
+	default:
+		writeReport(out, report, password, baseline)
+	}
+	if err != nil {
+		return fmt.Errorf("Error writing report: %w", err)
+	}
 
-	password := resolvePassword(cfg)
+	if cfg.CSVPath != "" {
+		if err := writeCSVFile(cfg.CSVPath, report); err != nil {
+			return fmt.Errorf("Error writing CSV: %w", err)
+		}
+	}
 
-	fmt.Println("Bcrypt Cost Benchmark")
-	fmt.Println("=====================")
-	fmt.Println()
+	if cfg.PlotPath != "" {
+		if err := writePlotFile(cfg.PlotPath, report); err != nil {
+			return fmt.Errorf("Error writing plot: %w", err)
+		}
+	}
 
-	results := runBenchmark(cfg, password)
+	if cfg.LogPath != "" {
+		if err := appendHistory(cfg.LogPath, report); err != nil {
+			return fmt.Errorf("Error appending to log: %w", err)
+		}
+	}
 
-	printReport(cfg, password, results)
+	if cfg.FailOver > 0 {
+		return checkFailOver(cfg, report)
+	}
+	return nil
 }
 
-func parseFlags() Config {
-	cfg := Config{}
+func parseFlags(args []string) (Config, error) {
+	cfg := Config{Command: commandSweep}
+	if len(args) > 0 && (args[0] == commandSweep || args[0] == commandSingle) {
+		cfg.Command, args = args[0], args[1:]
+	}
 
-	flag.IntVar(&cfg.StartCost, "start", 10, "Starting cost value")
-	flag.IntVar(&cfg.EndCost, "end", 16, "Ending cost value")
-	flag.StringVar(&cfg.Password, "password", "correct-horse-battery-staple", "Password to hash")
-	flag.IntVar(&cfg.GenerateLength, "generate", 0, "Generate random password of given length (overrides -password)")
-	flag.IntVar(&cfg.Iterations, "iterations", 3, "Number of iterations per cost level")
+	program := filepath.Base(os.Args[0])
+	fs := flag.NewFlagSet(program+" "+cfg.Command, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s [sweep] [flags]\n       %s single <cost> [flags]\n\nFlags:\n", program, program)
+		fs.PrintDefaults()
+	}
 
-	flag.Parse()
+	// The cost range and the modes built on the start cost only make sense
+	// for a sweep; single takes its one cost as an argument.
+	if cfg.Command == commandSweep {
+		fs.IntVar(&cfg.StartCost, "start", 10, "Starting cost value")
+		fs.IntVar(&cfg.EndCost, "end", 16, "Ending cost value")
+		fs.Func("costs", "Comma-separated costs to benchmark in this order, e.g. 10,12,14 (overrides -start, -end and -step)", func(value string) error {
+			costs, err := parseCostList(value)
+			cfg.CostList = costs
+			return err
+		})
+		fs.IntVar(&cfg.Step, "step", 1, "Benchmark every nth cost from the start; the end cost is always included")
+		fs.DurationVar(&cfg.Target, "target", 0, "Find the highest cost whose median stays within this duration, e.g. 250ms")
+		fs.BoolVar(&cfg.Knee, "knee", false, "Ramp concurrency at the start cost until per-hash latency degrades")
+		fs.Float64Var(&cfg.KneeThreshold, "knee-threshold", 50, "Latency increase in percent over single-threaded that counts as degraded")
+		fs.IntVar(&cfg.KneeMax, "knee-max", 4*runtime.NumCPU(), "Maximum concurrency to try in knee mode")
+		fs.BoolVar(&cfg.Encodings, "encodings", false, "Compare raw, UTF-8 multibyte and SHA-256 pre-hashed passwords at the start cost")
+		fs.StringVar(&cfg.AuditPath, "audit", "", "Audit a file of bcrypt hashes, one per line, instead of benchmarking")
+	}
+	fs.StringVar(&cfg.Algorithm, "algo", bench.AlgoBcrypt, "Hashing scheme: bcrypt, scrypt (cost is log2 N) or argon2id (cost is log2 of memory in KiB)")
+	fs.StringVar(&cfg.Password, "password", "correct-horse-battery-staple", "Password to hash")
+	fs.StringVar(&cfg.Password2, "password2", "", "Also sweep this second password and compare the means side by side")
+	fs.IntVar(&cfg.GenerateLength, "generate", 0, "Generate a random password of this length (1 to 1024)")
+	seed := fs.Int64("seed", 0, "Derive -generate passwords and the run ID from this seed instead of crypto/rand (not for real passwords)")
+	fs.BoolVar(&cfg.Quiet, "quiet", false, "Print only the results table, without banner, configuration, analysis, progress or warnings")
+	fs.StringVar(&cfg.Progress, "progress", progressAuto, "Progress display: auto, animated or plain (one line per completed cost)")
+	fs.BoolVar(&cfg.Verbose, "verbose", false, "Print every individual duration as each cost completes")
+	fs.StringVar(&cfg.PasswordFile, "password-file", "", "Read the password from a file (- for stdin)")
+	defaultIterations := 3
+	if cfg.Command == commandSingle {
+		// With one cost to measure, there is time for a distribution.
+		defaultIterations = 20
+	}
+	fs.IntVar(&cfg.Iterations, "iterations", defaultIterations, "Number of iterations per cost level")
+	auto := fs.Bool("auto", false, "Keep sampling each cost until its coefficient of variation drops below -auto-cv")
+	fs.Float64Var(&cfg.AutoCV, "auto-cv", 5, "Coefficient of variation in percent that ends -auto sampling")
+	fs.IntVar(&cfg.AutoMax, "auto-max", 100, "Maximum iterations per cost level with -auto")
+	fs.DurationVar(&cfg.HashTimeout, "hash-timeout", 0, "Give up on any hash that takes longer than this, e.g. 30s, and skip that cost and every higher one")
+	fs.BoolVar(&cfg.Interleave, "interleave", false, "Take one iteration of every cost per round instead of finishing each cost in turn")
+	fs.IntVar(&cfg.Repeat, "repeat", 1, "Run the whole sweep this many times and merge the samples per cost")
+	fs.Func("percentiles", "Comma-separated percentiles for the results table, e.g. 50,90,99,99.9 (default 25,50,75,95,99)", func(value string) error {
+		percentiles, err := parsePercentileList(value)
+		cfg.Percentiles = percentiles
+		return err
+	})
+	fs.IntVar(&cfg.MinCostFloor, "min-cost-floor", 0, "Never recommend a cost below this value (0 disables)")
+	fs.StringVar(&cfg.Format, "format", formatText, "Output format: text, json, ascii-table, markdown, stable, grafana, ndjson-metrics or prometheus")
+	fs.BoolVar(&cfg.NoColor, "no-color", false, "Never color the analysis, even on a terminal (NO_COLOR is also honored)")
+	fs.BoolVar(&cfg.Plain, "plain", false, "Draw ascii-table borders with plain ASCII instead of box-drawing characters")
+	fs.StringVar(&cfg.BaselinePath, "baseline", "", "Compare means against a results file saved with -format json")
+	fs.BoolVar(&cfg.Histogram, "histogram", false, "Print a histogram of the samples at each cost after the results table")
+	fs.StringVar(&cfg.OutputPath, "output", "", "Write the report to this file; progress stays on the terminal")
+	fs.DurationVar(&cfg.FailOver, "fail-over", 0, "Exit with an error after the report if a cost's mean exceeds this, e.g. 400ms")
+	fs.IntVar(&cfg.FailCost, "fail-cost", 0, "Only apply -fail-over to this cost")
+	fs.StringVar(&cfg.PlotPath, "plot", "", "Also draw mean latency against cost as an SVG chart at this path")
+	fs.BoolVar(&cfg.PlotLog, "plot-log", false, "Use a logarithmic latency axis in the -plot chart")
+	fs.StringVar(&cfg.CacheDir, "cache", "", "Reuse results cached in this directory for costs measured recently, and cache new ones")
+	fs.DurationVar(&cfg.CacheTTL, "cache-ttl", time.Hour, "How long a cached result stays valid")
+	fs.BoolVar(&cfg.NoCache, "no-cache", false, "Measure every cost even if -cache has it, and refresh the cache")
+	fs.StringVar(&cfg.LogPath, "log", "", "Append one JSON line per run to this history file")
+	fs.StringVar(&cfg.CSVPath, "csv", "", "Also write results as CSV to this path (- for stdout)")
+	fs.DurationVar(&cfg.Budget, "budget", 0, "Keep sampling each cost until this much time is spent on it, e.g. 5s")
+	fs.IntVar(&cfg.MinSamples, "min-samples", 0, "With -budget, take at least this many samples per cost level however long they take")
+	fs.IntVar(&cfg.MaxSamples, "max-samples", 0, "With -budget, take at most this many samples per cost level (0 means no limit)")
+	fs.BoolVar(&cfg.MeasureAllocs, "memstats", false, "Record heap bytes and allocations per hash (reads runtime.MemStats around every iteration)")
+	fs.Float64Var(&cfg.Trim, "trim", 0, "Drop this percentage of the fastest and of the slowest samples before computing mean and stddev")
+	fs.IntVar(&cfg.Warmup, "warmup", 1, "Untimed hashes to run per cost before measuring")
+	fs.DurationVar(&cfg.ThresholdFast, "threshold-fast", 100*time.Millisecond, "Medians below this are rated Fast")
+	fs.DurationVar(&cfg.ThresholdGood, "threshold-good", recommendationBudget, "Medians below this are rated Good; also the recommendation budget")
+	fs.DurationVar(&cfg.ThresholdAcceptable, "threshold-acceptable", 500*time.Millisecond, "Medians below this are rated Acceptable")
+	fs.DurationVar(&cfg.ThresholdSlow, "threshold-slow", time.Second, "Medians below this are rated Slow; anything slower is Too slow")
+	fs.IntVar(&cfg.Concurrency, "concurrency", 1, "Number of concurrent hashes per iteration")
+	fs.IntVar(&cfg.MaxProcs, "maxprocs", 0, "Set GOMAXPROCS before benchmarking (0 leaves the default)")
+	fs.StringVar(&cfg.Mode, "mode", modeGenerate, "What to benchmark: generate, compare (verification) or both")
+	fs.Float64Var(&cfg.InstanceCost, "instance-cost", 0, "Hourly instance price in dollars, for cost-per-hash estimates")
+	fs.IntVar(&cfg.InstanceVCPUs, "instance-vcpus", 0, "vCPU count of the instance priced by -instance-cost")
+	fs.BoolVar(&cfg.DryRun, "dry-run", false, "Time one hash at the start cost, print the estimated sweep duration and exit")
+	fs.BoolVar(&cfg.AllowRoot, "allow-root", false, "Do not warn when running as root")
 
+	var costArg string
+	if cfg.Command == commandSingle && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		costArg, args = args[0], args[1:]
+	}
+	fs.Parse(args)
+	if cfg.Command == commandSingle && costArg == "" && fs.NArg() > 0 {
+		costArg = fs.Arg(0)
+		fs.Parse(fs.Args()[1:])
+	}
+	if fs.NArg() > 0 {
+		return cfg, fmt.Errorf("Unexpected argument %q", fs.Arg(0))
+	}
+	if cfg.Command == commandSingle {
+		cost, err := strconv.Atoi(costArg)
+		if err != nil {
+			return cfg, fmt.Errorf("Single needs one cost, e.g. %s single 12", program)
+		}
+		if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+			return cfg, fmt.Errorf("Cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+		cfg.StartCost, cfg.EndCost, cfg.Step = cost, cost, 1
+	}
+
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	if len(cfg.CostList) > 0 {
+		cfg.StartCost, cfg.EndCost = slices.Min(cfg.CostList), slices.Max(cfg.CostList)
+	}
+	if set["seed"] {
+		cfg.Seed = seed
+	}
+	if cfg.Budget > 0 && !set["iterations"] {
+		cfg.Iterations = 1
+	}
+	if cfg.Command == commandSingle && !set["histogram"] && isHumanFormat(cfg.Format) {
+		cfg.Histogram = true
+	}
+	if !*auto {
+		if set["auto-cv"] || set["auto-max"] {
+			return cfg, errors.New("-auto-cv and -auto-max require -auto")
+		}
+		cfg.AutoCV, cfg.AutoMax = 0, 0
+	}
+
+	if set["generate"] && (cfg.GenerateLength < 1 || cfg.GenerateLength > maxGenerateLength) {
+		return cfg, fmt.Errorf("Generate length must be between 1 and %d", maxGenerateLength)
+	}
+
+	sources := 0
+	for _, given := range []bool{set["password"], cfg.GenerateLength > 0, cfg.PasswordFile != ""} {
+		if given {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return cfg, errors.New("Only one of -password, -generate and -password-file may be given")
+	}
+
+	switch cfg.Algorithm {
+	case bench.AlgoBcrypt:
+	case bench.AlgoScrypt, bench.AlgoArgon2id:
+		if cfg.EndCost > maxKDFCost {
+			return cfg, fmt.Errorf("End cost must be at most %d for %s", maxKDFCost, cfg.Algorithm)
+		}
+		if cfg.Knee || cfg.Encodings || cfg.AuditPath != "" {
+			return cfg, errors.New("Knee, encodings and audit modes only support bcrypt")
+		}
+	default:
+		return cfg, fmt.Errorf("Unknown algorithm %q", cfg.Algorithm)
+	}
+	for _, cost := range cfg.CostList {
+		if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+			return cfg, fmt.Errorf("Cost %d in -costs must be between %d and %d", cost, bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	}
 	if cfg.StartCost < bcrypt.MinCost {
-		log.Fatalf("Start cost must be at least %d", bcrypt.MinCost)
+		return cfg, fmt.Errorf("Start cost must be at least %d", bcrypt.MinCost)
 	}
 	if cfg.EndCost > bcrypt.MaxCost {
-		log.Fatalf("End cost must be at most %d", bcrypt.MaxCost)
+		return cfg, fmt.Errorf("End cost must be at most %d", bcrypt.MaxCost)
 	}
 	if cfg.StartCost > cfg.EndCost {
-		log.Fatal("Start cost must be less than or equal to end cost")
+		return cfg, errors.New("Start cost must be less than or equal to end cost")
+	}
+	if cfg.Repeat < 1 {
+		return cfg, errors.New("Repeat must be at least 1")
+	}
+	if cfg.Step < 1 {
+		return cfg, errors.New("Step must be at least 1")
 	}
 	if cfg.Iterations < 1 {
-		log.Fatal("Iterations must be at least 1")
+		return cfg, errors.New("Iterations must be at least 1")
+	}
+	if cfg.MinCostFloor != 0 && (cfg.MinCostFloor < bcrypt.MinCost || cfg.MinCostFloor > bcrypt.MaxCost) {
+		return cfg, fmt.Errorf("Minimum cost floor must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	switch cfg.Format {
+	case formatText, formatJSON, formatASCIITable, formatStable, formatGrafana, formatNDJSON, formatMarkdown, formatPrometheus:
+	default:
+		return cfg, fmt.Errorf("Unknown format %q", cfg.Format)
+	}
+	if cfg.Budget < 0 {
+		return cfg, errors.New("Budget must not be negative")
+	}
+	if *auto {
+		if cfg.Budget > 0 {
+			return cfg, errors.New("-auto and -budget cannot be combined")
+		}
+		if cfg.AutoCV <= 0 {
+			return cfg, errors.New("Auto CV threshold must be greater than 0")
+		}
+		if cfg.AutoMax < cfg.Iterations {
+			return cfg, errors.New("Auto maximum iterations must be at least -iterations")
+		}
+	}
+	if (cfg.MinSamples != 0 || cfg.MaxSamples != 0) && cfg.Budget == 0 {
+		return cfg, errors.New("-min-samples and -max-samples require -budget")
+	}
+	if cfg.MinSamples < 0 || cfg.MaxSamples < 0 {
+		return cfg, errors.New("Sample bounds must not be negative")
+	}
+	if cfg.MaxSamples > 0 && cfg.MaxSamples < max(cfg.MinSamples, cfg.Iterations*cfg.Concurrency) {
+		return cfg, errors.New("Maximum samples must be at least -min-samples and -iterations times -concurrency")
+	}
+	if cfg.Trim < 0 || cfg.Trim >= 50 {
+		return cfg, errors.New("Trim must be at least 0 and less than 50 percent")
+	}
+	if cfg.Warmup < 0 {
+		return cfg, errors.New("Warmup must not be negative")
+	}
+	if cfg.ThresholdFast <= 0 || cfg.ThresholdFast >= cfg.ThresholdGood ||
+		cfg.ThresholdGood >= cfg.ThresholdAcceptable || cfg.ThresholdAcceptable >= cfg.ThresholdSlow {
+		return cfg, errors.New("Thresholds must be positive and increase from -threshold-fast to -threshold-slow")
+	}
+	if cfg.MaxProcs < 0 {
+		return cfg, errors.New("Maxprocs must not be negative")
+	}
+	if cfg.Concurrency < 1 {
+		return cfg, errors.New("Concurrency must be at least 1")
+	}
+	if cfg.Target < 0 {
+		return cfg, errors.New("Target must not be negative")
+	}
+	switch cfg.Mode {
+	case modeGenerate, modeCompare, modeBoth:
+	default:
+		return cfg, fmt.Errorf("Unknown mode %q", cfg.Mode)
+	}
+	if cfg.InstanceCost < 0 || cfg.InstanceVCPUs < 0 {
+		return cfg, errors.New("Instance cost and vCPU count must not be negative")
+	}
+	if (cfg.InstanceCost > 0) != (cfg.InstanceVCPUs > 0) {
+		return cfg, errors.New("-instance-cost and -instance-vcpus must be given together")
+	}
+	if cfg.AuditPath != "" && !isHumanFormat(cfg.Format) {
+		return cfg, errors.New("Audit mode only supports the text format")
+	}
+	if cfg.Password2 != "" && (cfg.Knee || cfg.Encodings || cfg.AuditPath != "") {
+		return cfg, errors.New("-password2 only applies to the cost sweep")
+	}
+	if cfg.DryRun && (cfg.Knee || cfg.Encodings || cfg.AuditPath != "") {
+		return cfg, errors.New("-dry-run only estimates the cost sweep")
+	}
+	switch cfg.Progress {
+	case progressAuto, progressAnimated, progressPlain:
+	default:
+		return cfg, fmt.Errorf("Unknown progress display %q", cfg.Progress)
+	}
+	if cfg.FailOver < 0 {
+		return cfg, errors.New("Fail-over limit must not be negative")
+	}
+	if cfg.FailCost != 0 {
+		if cfg.FailOver == 0 {
+			return cfg, errors.New("-fail-cost requires -fail-over")
+		}
+		if !slices.Contains(cfg.Costs(), cfg.FailCost) {
+			return cfg, fmt.Errorf("Cost %d given to -fail-cost is not part of the sweep", cfg.FailCost)
+		}
+	}
+	if cfg.HashTimeout < 0 {
+		return cfg, errors.New("Hash timeout must not be negative")
+	}
+	if cfg.HashTimeout > 0 && (cfg.Knee || cfg.Encodings || cfg.AuditPath != "") {
+		return cfg, errors.New("-hash-timeout only applies to the cost sweep")
+	}
+	if cfg.PlotLog && cfg.PlotPath == "" {
+		return cfg, errors.New("-plot-log requires -plot")
+	}
+	if cfg.PlotPath != "" && (cfg.Knee || cfg.Encodings || cfg.AuditPath != "") {
+		return cfg, errors.New("-plot only applies to the cost sweep")
+	}
+	if cfg.CacheTTL <= 0 {
+		return cfg, errors.New("Cache TTL must be positive")
+	}
+	if cfg.NoCache && cfg.CacheDir == "" {
+		return cfg, errors.New("-no-cache requires -cache")
+	}
+	if cfg.CacheDir != "" && cfg.Target > 0 {
+		return cfg, errors.New("-cache cannot be combined with -target, which stops the sweep at the first cost over the target")
+	}
+	if cfg.CacheDir != "" && (cfg.Knee || cfg.Encodings || cfg.AuditPath != "") {
+		return cfg, errors.New("-cache only applies to the cost sweep")
+	}
+	if cfg.Interleave && cfg.Target > 0 {
+		return cfg, errors.New("-interleave samples every cost at once, so it cannot stop early for -target")
+	}
+	if cfg.Verbose && cfg.Quiet {
+		return cfg, errors.New("-verbose and -quiet cannot be combined")
+	}
+	if cfg.Histogram && !isHumanFormat(cfg.Format) {
+		return cfg, errors.New("Histograms are only printed with the text and ascii-table formats")
+	}
+	if cfg.Encodings && !isHumanFormat(cfg.Format) {
+		return cfg, errors.New("Encodings mode only supports the text format")
+	}
+	if cfg.Knee {
+		if !isHumanFormat(cfg.Format) {
+			return cfg, errors.New("Knee mode only supports the text format")
+		}
+		if cfg.KneeThreshold <= 0 {
+			return cfg, errors.New("Knee threshold must be greater than 0")
+		}
+		if cfg.KneeMax < 1 {
+			return cfg, errors.New("Knee maximum concurrency must be at least 1")
+		}
 	}
 
-	return cfg
+	return cfg, nil
 }
 
-func resolvePassword(cfg Config) []byte {
+func resolvePassword(cfg Config) ([]byte, error) {
 	if cfg.GenerateLength > 0 {
-		return generateRandomPassword(cfg.GenerateLength)
+		password, err := generateRandomPassword(cfg.GenerateLength, randomSource(cfg.Seed, seedStreamPassword))
+		if err != nil {
+			return nil, fmt.Errorf("Error generating random password: %w", err)
+		}
+		return password, nil
 	}
-	return []byte(cfg.Password)
+	if cfg.PasswordFile != "" {
+		password, err := readPasswordFile(cfg.PasswordFile)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading password: %w", err)
+		}
+		return password, nil
+	}
+	return []byte(cfg.Password), nil
 }
 
-func generateRandomPassword(length int) []byte {
-	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789!@#$%^&*"
-	password := make([]byte, length)
-	randomBytes := make([]byte, length)
+// limitPasswordLength truncates passwords to the 72 bytes bcrypt actually
+// uses. golang.org/x/crypto/bcrypt rejects longer input outright, while most
+// other implementations silently ignore the excess.
+func limitPasswordLength(password []byte, quiet bool) []byte {
+	if len(password) <= bcryptMaxPasswordBytes {
+		return password
+	}
 
-	_, err := rand.Read(randomBytes)
-	if err != nil {
-		log.Fatalf("Error generating random password: %v", err)
+	if !quiet {
+		fmt.Fprintf(os.Stderr, "Warning: the password is %d bytes, but bcrypt only uses the first %d.\n",
+			len(password), bcryptMaxPasswordBytes)
+		fmt.Fprintf(os.Stderr, "         Benchmarking the first %d bytes; the extra length does not affect timing.\n",
+			bcryptMaxPasswordBytes)
 	}
+	return password[:bcryptMaxPasswordBytes]
+}
 
-	for i := range length {
-		password[i] = charset[randomBytes[i]%byte(len(charset))]
+// passwordLength describes a password of original bytes that was benchmarked
+// as its first used bytes.
+func passwordLength(original, used int) string {
+	if original > used {
+		return fmt.Sprintf("%d characters (truncated to the first %d bcrypt uses)", original, used)
 	}
+	return fmt.Sprintf("%d characters", used)
+}
 
-	return password
+func readPasswordFile(path string) ([]byte, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if trimmed, ok := bytes.CutSuffix(data, []byte("\n")); ok {
+		data, _ = bytes.CutSuffix(trimmed, []byte("\r"))
+	}
+	return data, nil
 }
 
-func runBenchmark(cfg Config, password []byte) []CostResult {
-	results := make([]CostResult, 0, cfg.EndCost-cfg.StartCost+1)
-	spinnerIdx := 0
+const passwordCharset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789!@#$%^&*"
 
-	for cost := cfg.StartCost; cost <= cfg.EndCost; cost++ {
-		durations := make([]time.Duration, 0, cfg.Iterations)
+func generateRandomPassword(length int, src io.Reader) ([]byte, error) {
+	const charset = passwordCharset
+	// Bytes at or above limit are rejected: keeping them would make the
+	// first 256 % len(charset) characters more likely than the rest.
+	const limit = 256 - 256%len(charset)
 
-		for iter := 1; iter <= cfg.Iterations; iter++ {
-			spinnerIdx = (spinnerIdx + 1) % len(spinnerFrames)
-			fmt.Printf("\r%s Running: cost=%d, iteration=%d/%d    ",
-				spinnerFrames[spinnerIdx], cost, iter, cfg.Iterations)
+	password := make([]byte, 0, length)
+	randomBytes := make([]byte, length)
 
-			start := time.Now()
-			_, err := bcrypt.GenerateFromPassword(password, cost)
-			if err != nil {
-				log.Fatalf("\nError generating hash: %v", err)
+	for len(password) < length {
+		if _, err := io.ReadFull(src, randomBytes); err != nil {
+			return nil, err
+		}
+
+		for _, b := range randomBytes {
+			if int(b) < limit && len(password) < length {
+				password = append(password, charset[int(b)%len(charset)])
 			}
-			durations = append(durations, time.Since(start))
 		}
+	}
+
+	return password, nil
+}
 
-		results = append(results, calculateStats(cost, durations))
+// parseCostList parses -costs. Range checks happen with the other cost
+// validation once the algorithm is known.
+func parseCostList(value string) ([]int, error) {
+	var costs []int
+	for _, field := range strings.Split(value, ",") {
+		cost, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, fmt.Errorf("invalid cost %q", field)
+		}
+		if slices.Contains(costs, cost) {
+			return nil, fmt.Errorf("cost %d is listed twice", cost)
+		}
+		costs = append(costs, cost)
 	}
+	return costs, nil
+}
 
-	fmt.Print("\r\033[K")
+// maxGenerateLength bounds -generate. bcrypt only uses the first 72 bytes,
+// and no scheme needs kilobytes of password to be benchmarked faithfully.
+const maxGenerateLength = 1024
 
-	return results
+const (
+	commandSweep  = "sweep"
+	commandSingle = "single"
+)
+
+// maxKDFCost caps the scrypt and argon2id cost, where each step doubles the
+// memory used: cost 24 already needs 16 GiB.
+const maxKDFCost = 24
+
+func parsePercentileList(value string) ([]float64, error) {
+	var percentiles []float64
+	for _, field := range strings.Split(value, ",") {
+		p, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid percentile %q", field)
+		}
+		if p < 0 || p > 100 {
+			return nil, fmt.Errorf("percentile %g is not between 0 and 100", p)
+		}
+		if slices.Contains(percentiles, p) {
+			return nil, fmt.Errorf("percentile %g is listed twice", p)
+		}
+		percentiles = append(percentiles, p)
+	}
+	return percentiles, nil
 }
 
-func calculateStats(cost int, durations []time.Duration) CostResult {
-	sorted := make([]time.Duration, len(durations))
-	copy(sorted, durations)
-	slices.Sort(sorted)
-
-	mean := calculateMean(sorted)
-	stdDev := calculateStdDev(sorted, mean)
-
-	return CostResult{
-		Cost:       cost,
-		Durations:  durations,
-		Mean:       mean,
-		StdDev:     stdDev,
-		P25:        calculatePercentile(sorted, 25),
-		P75:        calculatePercentile(sorted, 75),
-		P95:        calculatePercentile(sorted, 95),
-		P99:        calculatePercentile(sorted, 99),
-		Iterations: len(durations),
+func algorithmDescription(algo string) string {
+	switch algo {
+	case bench.AlgoScrypt:
+		return fmt.Sprintf("scrypt (N = 2^cost, r=%d, p=%d)", bench.ScryptR, bench.ScryptP)
+	case bench.AlgoArgon2id:
+		return fmt.Sprintf("argon2id (memory = 2^cost KiB, time=%d, threads=%d)", bench.Argon2Time, bench.Argon2Threads)
 	}
+	return algo
 }
 
-func calculateMean(durations []time.Duration) time.Duration {
-	var total time.Duration
-	for _, d := range durations {
-		total += d
+func writeDryRun(out io.Writer, cfg Config, password []byte) error {
+	estimate, err := bench.Estimate(cfg.Config, password)
+	if err != nil {
+		return fmt.Errorf("Error running benchmark: %w", err)
 	}
-	return total / time.Duration(len(durations))
+	sweeps := 1
+	if cfg.Mode == modeBoth {
+		sweeps++
+	}
+	if cfg.Password2 != "" {
+		sweeps++
+	}
+	estimate *= time.Duration(sweeps)
+
+	costs := cfg.Costs()
+	fmt.Fprintf(out, "Estimated runtime: ~%s for %d cost levels from %d to %d\n",
+		estimate.Round(time.Second), len(costs), costs[0], costs[len(costs)-1])
+	fmt.Fprintln(out, "  Extrapolated from one hash at the start cost, assuming each cost increment doubles the time.")
+	if cfg.AutoCV > 0 {
+		fmt.Fprintf(out, "  With -auto this assumes %d iterations per cost; noisy costs run up to %d.\n", cfg.Iterations, cfg.AutoMax)
+	}
+	return nil
 }
 
-func calculateStdDev(durations []time.Duration, mean time.Duration) time.Duration {
-	if len(durations) < 2 {
-		return 0
+type sweepFunc func(context.Context, bench.Config, []byte) ([]bench.CostResult, error)
+
+// runSweep measures the costs the cache has no entry for and returns them
+// merged with the cached ones in sweep order. If a hash timed out, it also
+// returns the cutoff, and no cost at or above it.
+func runSweep(ctx context.Context, run sweepFunc, operation string, cfg Config, password []byte, spin *spinner, cache *resultCache) ([]bench.CostResult, *Cutoff, error) {
+	cached := make(map[int]bench.CostResult)
+	var missing []int
+	for _, cost := range cfg.Costs() {
+		if r, ok := cache.load(cfg, operation, len(password), cost); ok {
+			cached[cost] = r
+		} else {
+			missing = append(missing, cost)
+		}
+	}
+
+	var measured []bench.CostResult
+	var cutoff *Cutoff
+	if len(cached) == 0 || len(missing) > 0 {
+		sweepCfg := cfg.Config
+		if len(cached) > 0 {
+			sweepCfg.CostList = missing
+		}
+		var err error
+		measured, err = run(ctx, sweepCfg, password)
+		spin.clear()
+
+		var timeout *bench.TimeoutError
+		if errors.As(err, &timeout) {
+			cutoff = &Cutoff{Operation: operation, Cost: timeout.Cost}
+			maps.DeleteFunc(cached, func(cost int, _ bench.CostResult) bool { return cost >= timeout.Cost })
+		} else if err != nil && !errors.Is(err, context.Canceled) {
+			return nil, nil, fmt.Errorf("Error running benchmark: %w", err)
+		}
 	}
 
-	var sumSquares float64
-	for _, d := range durations {
-		diff := float64(d - mean)
-		sumSquares += diff * diff
+	// With -repeat, an interrupted sweep can report a cost with fewer passes
+	// than requested, so nothing is cached from it.
+	if ctx.Err() == nil {
+		for _, r := range measured {
+			if err := cache.store(cfg, operation, len(password), r); err != nil {
+				return nil, nil, fmt.Errorf("Error writing cache: %w", err)
+			}
+		}
+	}
+	if len(cached) == 0 {
+		return measured, cutoff, nil
 	}
 
-	variance := sumSquares / float64(len(durations)-1)
-	return time.Duration(math.Sqrt(variance))
+	results := make([]bench.CostResult, 0, len(cfg.Costs()))
+	for _, cost := range cfg.Costs() {
+		if r, ok := cached[cost]; ok {
+			results = append(results, r)
+			continue
+		}
+		for _, r := range measured {
+			if r.Cost == cost {
+				results = append(results, r)
+			}
+		}
+	}
+	return results, cutoff, nil
 }
 
-func calculatePercentile(sorted []time.Duration, percentile float64) time.Duration {
-	if len(sorted) == 0 {
-		return 0
+func writeReport(out io.Writer, report Report, password []byte, baseline *Report) {
+	cfg, results := report.Config, report.Results
+
+	if !cfg.Quiet {
+		fmt.Fprintln(out, "Benchmark Configuration")
+		fmt.Fprintln(out, "-----------------------")
+		writeConfiguration(out, report, password, baseline)
+
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Environment")
+		fmt.Fprintln(out, "-----------")
+		writeEnvironment(out, report)
 	}
-	if len(sorted) == 1 {
-		return sorted[0]
+
+	var baselineResults, baselineVerify []bench.CostResult
+	if baseline != nil {
+		baselineResults, baselineVerify = baseline.Results, baseline.VerifyResults
 	}
 
-	rank := (percentile / 100) * float64(len(sorted)-1)
-	lower := int(rank)
-	upper := lower + 1
+	switch cfg.Mode {
+	case modeCompare:
+		writeResultsTable(out, cfg, "Verify Results", results, baselineResults)
+	case modeBoth:
+		writeResultsTable(out, cfg, "Hash Results", results, baselineResults)
+		writeResultsTable(out, cfg, "Verify Results", report.VerifyResults, baselineVerify)
+	default:
+		writeResultsTable(out, cfg, "Results", results, baselineResults)
+	}
 
-	if upper >= len(sorted) {
-		return sorted[len(sorted)-1]
+	if cfg.Histogram {
+		switch cfg.Mode {
+		case modeCompare:
+			writeHistograms(out, "Verify Distribution", results, terminalWidth())
+		case modeBoth:
+			writeHistograms(out, "Hash Distribution", results, terminalWidth())
+			writeHistograms(out, "Verify Distribution", report.VerifyResults, terminalWidth())
+		default:
+			writeHistograms(out, "Distribution", results, terminalWidth())
+		}
+	}
+
+	if len(report.Password2Results) > 0 {
+		writePasswordComparison(out, results, report.Password2Results)
 	}
 
-	weight := rank - float64(lower)
-	return time.Duration(float64(sorted[lower])*(1-weight) + float64(sorted[upper])*weight)
+	if !cfg.Quiet {
+		writeAnalysis(out, report)
+	}
 }
 
-func printReport(cfg Config, password []byte, results []CostResult) {
-	fmt.Println("Benchmark Configuration")
-	fmt.Println("-----------------------")
+func writeConfiguration(out io.Writer, report Report, password []byte, baseline *Report) {
+	cfg := report.Config
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintf(w, "Cost Range:\t%d - %d\n", cfg.StartCost, cfg.EndCost)
-	fmt.Fprintf(w, "Iterations:\t%d per cost level\n", cfg.Iterations)
-	fmt.Fprintf(w, "Password Length:\t%d characters\n", len(password))
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Run ID:\t%s\n", report.RunID)
+	fmt.Fprintf(w, "Algorithm:\t%s\n", algorithmDescription(cfg.Algorithm))
+	if cfg.Command == commandSingle {
+		fmt.Fprintf(w, "Cost:\t%d\n", cfg.StartCost)
+	} else if len(cfg.CostList) > 0 {
+		fmt.Fprintf(w, "Costs:\t%s\n", joinInts(cfg.CostList))
+	} else if cfg.Step > 1 {
+		fmt.Fprintf(w, "Cost Range:\t%d - %d, step %d\n", cfg.StartCost, cfg.EndCost, cfg.Step)
+	} else {
+		fmt.Fprintf(w, "Cost Range:\t%d - %d\n", cfg.StartCost, cfg.EndCost)
+	}
+	fmt.Fprintf(w, "Mode:\t%s\n", cfg.Mode)
+	if cfg.Budget > 0 {
+		fmt.Fprintf(w, "Iterations:\tat least %d per cost level\n", cfg.Iterations)
+		fmt.Fprintf(w, "Budget:\t%s per cost level\n", formatDuration(cfg.Budget))
+		switch {
+		case cfg.MinSamples > 0 && cfg.MaxSamples > 0:
+			fmt.Fprintf(w, "Samples:\t%d to %d per cost level\n", cfg.MinSamples, cfg.MaxSamples)
+		case cfg.MinSamples > 0:
+			fmt.Fprintf(w, "Samples:\tat least %d per cost level\n", cfg.MinSamples)
+		case cfg.MaxSamples > 0:
+			fmt.Fprintf(w, "Samples:\tat most %d per cost level\n", cfg.MaxSamples)
+		}
+	} else if cfg.AutoCV > 0 {
+		fmt.Fprintf(w, "Iterations:\t%d to %d per cost level, until the CV is below %g%%\n", cfg.Iterations, cfg.AutoMax, cfg.AutoCV)
+	} else {
+		fmt.Fprintf(w, "Iterations:\t%d per cost level\n", cfg.Iterations)
+	}
+	if cfg.Repeat > 1 {
+		if cfg.Budget > 0 || cfg.AutoCV > 0 {
+			fmt.Fprintf(w, "Repeat:\t%d passes over the sweep, samples merged per cost level\n", cfg.Repeat)
+		} else {
+			fmt.Fprintf(w, "Repeat:\t%d passes, %d samples per cost level\n",
+				cfg.Repeat, cfg.Iterations*cfg.Concurrency*cfg.Repeat)
+		}
+	}
+	fmt.Fprintf(w, "Warmup:\t%d per cost level\n", cfg.Warmup)
+	if cfg.Interleave {
+		fmt.Fprintf(w, "Interleave:\tone iteration of every cost per round\n")
+	}
+	if cfg.Trim > 0 {
+		fmt.Fprintf(w, "Trim:\t%g%% from each end before mean and stddev\n", cfg.Trim)
+	}
+	if cfg.Concurrency > 1 {
+		fmt.Fprintf(w, "Concurrency:\t%d hashes per iteration\n", cfg.Concurrency)
+	}
+	if cfg.HashTimeout > 0 {
+		fmt.Fprintf(w, "Hash Timeout:\t%s per hash, stopping at the first cost that exceeds it\n", cfg.HashTimeout)
+	}
+	if cfg.MeasureAllocs {
+		fmt.Fprintf(w, "Memstats:\tread around every iteration, adding to wall-clock time\n")
+	}
+	switch {
+	case cfg.NoCache:
+		fmt.Fprintf(w, "Cache:\t%s, refreshed by measuring every cost\n", cfg.CacheDir)
+	case cfg.CacheDir != "":
+		fmt.Fprintf(w, "Cache:\t%s, reusing results up to %s old\n", cfg.CacheDir, cfg.CacheTTL)
+	}
+	fmt.Fprintf(w, "Password Length:\t%s\n", passwordLength(cfg.PasswordLength, len(password)))
+	if cfg.Password2 != "" {
+		fmt.Fprintf(w, "Password 2 Length:\t%s\n", passwordLength(cfg.Password2Length, len(cfg.Password2)))
+	}
 	if cfg.GenerateLength > 0 {
-		fmt.Fprintf(w, "Password Source:\tGenerated (random)\n")
+		if cfg.Seed != nil {
+			fmt.Fprintf(w, "Password Source:\tGenerated from seed %d (NOT cryptographically random)\n", *cfg.Seed)
+		} else {
+			fmt.Fprintf(w, "Password Source:\tGenerated (random)\n")
+		}
+	} else if cfg.PasswordFile == "-" {
+		fmt.Fprintf(w, "Password Source:\tStandard input\n")
+	} else if cfg.PasswordFile != "" {
+		fmt.Fprintf(w, "Password Source:\tFile (%s)\n", cfg.PasswordFile)
 	} else {
 		fmt.Fprintf(w, "Password Source:\tProvided\n")
 	}
+	if cfg.Target > 0 {
+		fmt.Fprintf(w, "Target:\t%s\n", formatDuration(cfg.Target))
+	}
+	if cfg.MinCostFloor > 0 {
+		fmt.Fprintf(w, "Minimum Cost Floor:\t%d\n", cfg.MinCostFloor)
+	}
+	fmt.Fprintf(w, "Privilege:\t%s\n", report.Privilege)
+	fmt.Fprintf(w, "Implementation:\t%s\n", report.Implementation)
+	fmt.Fprintf(w, "Build Tags:\t%s\n", report.Implementation.BuildTags)
+	if baseline != nil {
+		fmt.Fprintf(w, "Baseline:\t%s (run %s, %d iterations per cost level)\n",
+			cfg.BaselinePath, baseline.RunID, baseline.Config.Iterations)
+	}
 	w.Flush()
 
-	fmt.Println()
-	fmt.Println("Results")
-	fmt.Println("-------")
-	fmt.Println()
+	if report.Interrupted {
+		fmt.Fprintln(out)
+		fmt.Fprintf(out, "Note: run interrupted; showing the %d cost levels completed before Ctrl-C\n", len(report.Results))
+	}
+	for _, c := range report.Cutoffs {
+		what := "hash"
+		if c.Operation == "verify" {
+			what = "verification"
+		}
+		fmt.Fprintln(out)
+		fmt.Fprintf(out, "Note: a %s at cost %d took longer than the %s -hash-timeout; that cost and every higher one were skipped\n",
+			what, c.Cost, cfg.HashTimeout)
+	}
+}
 
-	w = tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', tabwriter.TabIndent)
-	fmt.Fprintln(w, "Cost\tIterations\tMean\tStdDev\tP25\tP75\tP95\tP99\t")
-	fmt.Fprintln(w, "----\t----------\t----\t------\t---\t---\t---\t---\t")
+func writeAnalysis(out io.Writer, report Report) {
+	cfg, results := report.Config, report.Results
 
-	for _, r := range results {
-		fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%s\t%s\t%s\t%s\t\n",
-			r.Cost,
-			r.Iterations,
-			formatDuration(r.Mean),
-			formatDuration(r.StdDev),
-			formatDuration(r.P25),
-			formatDuration(r.P75),
-			formatDuration(r.P95),
-			formatDuration(r.P99),
-		)
+	if cfg.Mode == modeBoth {
+		writeHashVerifyComparison(out, results, report.VerifyResults)
+	}
+
+	budget := cfg.ThresholdGood
+	if cfg.Target > 0 {
+		budget = cfg.Target
+		writeTargetSearch(out, cfg, results)
 	}
-	w.Flush()
 
-	fmt.Println()
-	fmt.Println("Analysis")
-	fmt.Println("--------")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Analysis")
+	fmt.Fprintln(out, "--------")
 
+	color := colorEnabled(out, cfg.NoColor)
 	for _, r := range results {
-		var recommendation string
+		var recommendation, tint string
 		switch {
-		case r.Mean < 100*time.Millisecond:
-			recommendation = "Fast - consider higher cost for sensitive data"
-		case r.Mean < 250*time.Millisecond:
-			recommendation = "Good - balanced security and performance"
-		case r.Mean < 500*time.Millisecond:
-			recommendation = "Acceptable - may impact UX under load"
-		case r.Mean < 1*time.Second:
-			recommendation = "Slow - may cause timeouts under load"
+		case r.P50 < cfg.ThresholdFast:
+			recommendation, tint = "Fast - consider higher cost for sensitive data", colorGreen
+		case r.P50 < cfg.ThresholdGood:
+			recommendation, tint = "Good - balanced security and performance", colorGreen
+		case r.P50 < cfg.ThresholdAcceptable:
+			recommendation, tint = "Acceptable - may impact UX under load", colorYellow
+		case r.P50 < cfg.ThresholdSlow:
+			recommendation, tint = "Slow - may cause timeouts under load", colorRed
 		default:
-			recommendation = "Too slow - not recommended for production"
+			recommendation, tint = "Too slow - not recommended for production", colorRed
+		}
+		fmt.Fprintf(out, "  Cost %d: %s\n", r.Cost, colorize(color, tint, recommendation))
+	}
+	writeMonotonicityWarnings(out, "", results)
+	if cfg.Mode == modeBoth {
+		writeMonotonicityWarnings(out, "verify ", report.VerifyResults)
+	}
+	writeEmbeddedCostCheck(out, "", results)
+	if cfg.Mode == modeBoth {
+		writeEmbeddedCostCheck(out, "verify ", report.VerifyResults)
+	}
+	if cfg.AutoCV > 0 {
+		writeStabilityWarnings(out, "", cfg.AutoCV, results)
+		if cfg.Mode == modeBoth {
+			writeStabilityWarnings(out, "verify ", cfg.AutoCV, report.VerifyResults)
 		}
-		fmt.Printf("  Cost %d: %s\n", r.Cost, recommendation)
+	}
+
+	rec := recommendCost(results, budget, cfg.MinCostFloor)
+	writeRecommendation(out, rec, cfg.MinCostFloor)
+
+	if cfg.InstanceCost > 0 {
+		writeCloudCost(out, report, results, rec)
+	}
+}
+
+func writeResultsTable(out io.Writer, cfg Config, title string, results, baseline []bench.CostResult) {
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, title)
+	fmt.Fprintln(out, underline(title))
+	fmt.Fprintln(out)
+
+	header, rows := resultsTable(results)
+	if cfg.BaselinePath != "" {
+		header, rows = appendBaselineColumn(header, rows, results, baseline)
+	}
+	switch {
+	case cfg.Format == formatASCIITable && cfg.Plain:
+		writeBorderedTable(out, header, asciiOnly(rows), plainBorders)
+	case cfg.Format == formatASCIITable:
+		writeBorderedTable(out, header, rows, boxBorders)
+	default:
+		writeTabTable(out, header, rows)
+	}
+	writePercentileNote(out, "*", results)
+	writeCachedNote(out, results)
+
+	if cfg.BaselinePath != "" {
+		writeBaselineNotes(out, results, baseline)
 	}
 }
 
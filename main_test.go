@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/eldad/bcryptbenchmark/bench"
+)
+
+// TestMain runs the real main instead of the tests when the exit code tests
+// re-execute the test binary.
+func TestMain(m *testing.M) {
+	if os.Getenv("BCRYPTBENCHMARK_RUN_MAIN") == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func TestUsageErrorsExitWithCode2(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"unknown flag", []string{"-no-such-flag"}},
+		{"cost out of range", []string{"-start", "3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := exec.Command(os.Args[0], tt.args...)
+			cmd.Env = append(os.Environ(), "BCRYPTBENCHMARK_RUN_MAIN=1")
+			output, err := cmd.CombinedOutput()
+
+			var exit *exec.ExitError
+			if !errors.As(err, &exit) {
+				t.Fatalf("%v: err = %v, want an exit status; output:\n%s", tt.args, err, output)
+			}
+			if code := exit.ExitCode(); code != exitUsage {
+				t.Errorf("%v: exit code %d, want %d; output:\n%s", tt.args, code, exitUsage, output)
+			}
+		})
+	}
+}
+
+func TestGenerateRandomPasswordUniform(t *testing.T) {
+	const perChar = 10000
+	src := rand.NewChaCha8([32]byte{1})
+
+	password, err := generateRandomPassword(perChar*len(passwordCharset), src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts := make(map[byte]int)
+	for _, c := range password {
+		counts[c]++
+	}
+	// One standard deviation is about 100 here, so 5% is a generous margin
+	// that a plain modulo mapping, with characters about 9% over or 18%
+	// under, would still exceed.
+	for i := range len(passwordCharset) {
+		c := passwordCharset[i]
+		if n := counts[c]; n < perChar*95/100 || n > perChar*105/100 {
+			t.Errorf("character %q appeared %d times, want %d ± 5%%", c, n, perChar)
+		}
+		delete(counts, c)
+	}
+	for c, n := range counts {
+		t.Errorf("character %q outside the charset appeared %d times", c, n)
+	}
+}
+
+// cycleReader returns its bytes over and over.
+type cycleReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *cycleReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = r.data[r.pos%len(r.data)]
+		r.pos++
+	}
+	return len(p), nil
+}
+
+func TestGenerateRandomPasswordRejectsHighBytes(t *testing.T) {
+	// 210 is the first byte rejected with a 70-character charset; only
+	// every third byte is usable.
+	src := &cycleReader{data: []byte{210, 255, 5}}
+
+	password, err := generateRandomPassword(32, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := bytes.Repeat([]byte{passwordCharset[5]}, 32); !bytes.Equal(password, want) {
+		t.Errorf("generateRandomPassword = %q, want %q", password, want)
+	}
+}
+
+func TestParseFlagsGenerateBounds(t *testing.T) {
+	tests := []struct {
+		value   string
+		wantErr bool
+	}{
+		{"0", true},
+		{"-3", true},
+		{"1", false},
+		{"1024", false},
+		{"1025", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			cfg, err := parseFlags([]string{"-generate", tt.value})
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "Generate length must be between 1 and 1024") {
+					t.Errorf("-generate %s: error = %v, want the length bounds error", tt.value, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("-generate %s: unexpected error %v", tt.value, err)
+			}
+			if want, _ := strconv.Atoi(tt.value); cfg.GenerateLength != want {
+				t.Errorf("-generate %s: GenerateLength = %d", tt.value, cfg.GenerateLength)
+			}
+		})
+	}
+}
+
+func TestWritePlotOrdersPointsByCost(t *testing.T) {
+	var results []bench.CostResult
+	for _, cost := range []int{8, 4, 6} {
+		results = append(results, bench.CostResult{Cost: cost, Mean: time.Duration(cost) * time.Millisecond})
+	}
+
+	var b bytes.Buffer
+	if err := writePlot(&b, Report{Config: Config{Mode: modeGenerate}, Results: results}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, rest, ok := strings.Cut(b.String(), `<polyline points="`)
+	points, _, _ := strings.Cut(rest, `"`)
+	if !ok {
+		t.Fatal("no polyline in the plot")
+	}
+	previous := math.Inf(-1)
+	for _, point := range strings.Fields(points) {
+		xs, _, _ := strings.Cut(point, ",")
+		x, err := strconv.ParseFloat(xs, 64)
+		if err != nil {
+			t.Fatalf("bad point %q", point)
+		}
+		if x <= previous {
+			t.Errorf("polyline points %q do not run left to right", points)
+			break
+		}
+		previous = x
+	}
+}
+
+func TestMachineFormatsIncludeVerifyResults(t *testing.T) {
+	result := bench.CalculateStats(4, []time.Duration{time.Millisecond})
+	report := Report{
+		Config:        Config{Mode: modeBoth},
+		Results:       []bench.CostResult{result},
+		VerifyResults: []bench.CostResult{result},
+	}
+
+	writers := map[string]func(*bytes.Buffer) error{
+		"csv":     func(b *bytes.Buffer) error { return writeCSV(b, report) },
+		"stable":  func(b *bytes.Buffer) error { return writeStable(b, report, 8) },
+		"grafana": func(b *bytes.Buffer) error { return writeGrafana(b, report) },
+		"ndjson":  func(b *bytes.Buffer) error { return writeNDJSON(b, report) },
+	}
+	for name, write := range writers {
+		var b bytes.Buffer
+		if err := write(&b); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		hash, verify := strings.Count(b.String(), "hash"), strings.Count(b.String(), "verify")
+		if hash == 0 || hash != verify {
+			t.Errorf("%s: %d hash and %d verify records, want the same non-zero count:\n%s", name, hash, verify, b.String())
+		}
+	}
+}
+
+func TestMachineFormatsFollowPercentiles(t *testing.T) {
+	cfg := Config{Mode: modeGenerate}
+	cfg.Percentiles = []float64{50, 99.9}
+	result := bench.CalculateStats(4, []time.Duration{time.Millisecond, 2 * time.Millisecond})
+	result.Percentiles = bench.CalculatePercentiles(result.Durations, cfg.Percentiles)
+	report := Report{Config: cfg, Results: []bench.CostResult{result}}
+
+	var csvOut, stableOut bytes.Buffer
+	if err := writeCSV(&csvOut, report); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeStable(&stableOut, report, 8); err != nil {
+		t.Fatal(err)
+	}
+
+	header, _, _ := strings.Cut(csvOut.String(), "\n")
+	if want := "cost,iterations,mean_ms,stddev_ms,min_ms,p50_ms,p99.9_ms,max_ms,throughput,run_id,operation"; header != want {
+		t.Errorf("CSV header = %q, want %q", header, want)
+	}
+	if !strings.Contains(stableOut.String(), "min_us p50_us p99.9_us max_us\n") {
+		t.Errorf("stable fields do not follow -percentiles:\n%s", stableOut.String())
+	}
+	for _, line := range strings.Split(strings.TrimSpace(csvOut.String()), "\n") {
+		if n := strings.Count(line, ","); n != strings.Count(header, ",") {
+			t.Errorf("CSV line %q has %d commas, header has %d", line, n, strings.Count(header, ","))
+		}
+	}
+}
+
+func TestCacheKeyCoversSamplingSettings(t *testing.T) {
+	base := Config{}
+	base.Iterations, base.Warmup = 2, 1
+
+	more := base
+	more.Iterations = 30
+	if samplingKey(more) == samplingKey(base) {
+		t.Error("-iterations 30 shares a cache key with -iterations 2")
+	}
+
+	trimmed := base
+	trimmed.Trim = 10
+	trimmed.Percentiles = []float64{50}
+	if samplingKey(trimmed) != samplingKey(base) {
+		t.Error("-trim and -percentiles change the cache key, but they are recomputed on load")
+	}
+}
+
+func TestToFullwidthFitsBcryptLimit(t *testing.T) {
+	for _, n := range []int{1, 24, 28, 100} {
+		p := toFullwidth(bytes.Repeat([]byte("a"), n))
+		if len(p) > bcryptMaxPasswordBytes || !utf8.Valid(p) {
+			t.Errorf("toFullwidth of %d characters = %d bytes (valid UTF-8: %t), want at most %d valid bytes",
+				n, len(p), utf8.Valid(p), bcryptMaxPasswordBytes)
+		}
+		if want := min(n, bcryptMaxPasswordBytes/3); utf8.RuneCount(p) != want {
+			t.Errorf("toFullwidth of %d characters kept %d runes, want %d", n, utf8.RuneCount(p), want)
+		}
+	}
+}
+
+func TestPasswordLengthNotesTruncation(t *testing.T) {
+	if got, want := passwordLength(28, 28), "28 characters"; got != want {
+		t.Errorf("passwordLength(28, 28) = %q, want %q", got, want)
+	}
+	if got, want := passwordLength(100, 72), "100 characters (truncated to the first 72 bcrypt uses)"; got != want {
+		t.Errorf("passwordLength(100, 72) = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/eldad/bcryptbenchmark/bench"
+)
+
+// writeMarkdown renders the report for pasting into issues and wikis: the
+// configuration in a fenced block, then one GitHub-flavored table per result
+// set, with durations formatted as in the text report.
+func writeMarkdown(w io.Writer, report Report, password []byte, baseline *Report) error {
+	cfg := report.Config
+
+	var b bytes.Buffer
+	fmt.Fprintln(&b, "### Benchmark Configuration")
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "```")
+	writeConfiguration(&b, report, password, baseline)
+	fmt.Fprintln(&b, "```")
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "### Environment")
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "```")
+	writeEnvironment(&b, report)
+	fmt.Fprintln(&b, "```")
+
+	var baselineResults, baselineVerify []bench.CostResult
+	if baseline != nil {
+		baselineResults, baselineVerify = baseline.Results, baseline.VerifyResults
+	}
+
+	section := func(title string, results, baseline []bench.CostResult) {
+		fmt.Fprintln(&b)
+		fmt.Fprintf(&b, "### %s\n", title)
+		fmt.Fprintln(&b)
+
+		header, rows := resultsTable(results)
+		if cfg.BaselinePath != "" {
+			header, rows = appendBaselineColumn(header, rows, results, baseline)
+		}
+		writeMarkdownTable(&b, header, rows)
+		writePercentileNote(&b, "\\*", results)
+		writeCachedNote(&b, results)
+
+		if cfg.BaselinePath != "" {
+			writeBaselineNotes(&b, results, baseline)
+		}
+	}
+
+	switch cfg.Mode {
+	case modeCompare:
+		section("Verify Results", report.Results, baselineResults)
+	case modeBoth:
+		section("Hash Results", report.Results, baselineResults)
+		section("Verify Results", report.VerifyResults, baselineVerify)
+	default:
+		section("Results", report.Results, baselineResults)
+	}
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
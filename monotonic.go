@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/eldad/bcryptbenchmark/bench"
+)
+
+type monotonicityViolation struct {
+	Lower, Higher bench.CostResult
+	Spread        time.Duration
+}
+
+// monotonicityViolations flags consecutive costs where the higher one is not
+// meaningfully slower. Every increment should roughly double the time, so a
+// gain within the combined standard deviation of the two costs means the run
+// was disturbed, e.g. by thermal throttling or a background process.
+func monotonicityViolations(results []bench.CostResult) []monotonicityViolation {
+	results = slices.Clone(results)
+	slices.SortFunc(results, func(a, b bench.CostResult) int { return a.Cost - b.Cost })
+
+	var violations []monotonicityViolation
+	for i := 1; i < len(results); i++ {
+		lower, higher := results[i-1], results[i]
+		spread := time.Duration(math.Hypot(float64(lower.StdDev), float64(higher.StdDev)))
+		if higher.Mean-lower.Mean <= spread {
+			violations = append(violations, monotonicityViolation{lower, higher, spread})
+		}
+	}
+	return violations
+}
+
+// writeStabilityWarnings names the costs that -auto sampling left above the
+// CV threshold because they hit -auto-max first.
+func writeStabilityWarnings(out io.Writer, label string, cvPercent float64, results []bench.CostResult) {
+	for _, r := range results {
+		if !bench.Stable(r, cvPercent) {
+			fmt.Fprintf(out, "  Warning: %scost %d did not stabilize: CV %.1f%% after %d samples, above the %g%% threshold\n",
+				label, r.Cost, float64(r.StdDev)/float64(r.Mean)*100, r.Iterations, cvPercent)
+		}
+	}
+}
+
+func writeMonotonicityWarnings(out io.Writer, label string, results []bench.CostResult) {
+	for _, v := range monotonicityViolations(results) {
+		fmt.Fprintf(out, "  Warning: %scost %d (mean %s) is not meaningfully slower than cost %d (mean %s, combined stddev %s);\n",
+			label, v.Higher.Cost, formatDuration(v.Higher.Mean), v.Lower.Cost, formatDuration(v.Lower.Mean), formatDuration(v.Spread))
+		fmt.Fprintln(out, "  something disturbed the run, such as thermal throttling or background load; consider rerunning")
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// ndjsonRecord is one line of the ndjson-metrics format, one per cost and
+// statistic.
+type ndjsonRecord struct {
+	Operation    string  `json:"operation"`
+	Cost         int     `json:"cost"`
+	Statistic    string  `json:"statistic"`
+	ValueSeconds float64 `json:"value_seconds"`
+	Timestamp    string  `json:"timestamp"`
+	Host         string  `json:"host"`
+	RunID        string  `json:"run_id"`
+}
+
+func writeNDJSON(w io.Writer, report Report) error {
+	timestamp := report.Timestamp.Format(time.RFC3339)
+	enc := json.NewEncoder(w)
+
+	for _, op := range report.operations() {
+		for _, r := range op.Results {
+			for _, stat := range costStatistics(r) {
+				err := enc.Encode(ndjsonRecord{
+					Operation:    op.Operation,
+					Cost:         r.Cost,
+					Statistic:    stat.Name,
+					ValueSeconds: stat.Value.Seconds(),
+					Timestamp:    timestamp,
+					Host:         report.Host,
+					RunID:        report.RunID,
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
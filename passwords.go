@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"text/tabwriter"
+	"time"
+
+	"github.com/eldad/bcryptbenchmark/bench"
+)
+
+// writePasswordComparison shows the means of the two -password2 sweeps side
+// by side. The work is fixed by the cost, so a difference within the
+// combined standard deviation of a cost is noise.
+func writePasswordComparison(out io.Writer, first, second []bench.CostResult) {
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Password Comparison")
+	fmt.Fprintln(out, "-------------------")
+	fmt.Fprintln(out)
+
+	secondByCost := make(map[int]bench.CostResult, len(second))
+	for _, r := range second {
+		secondByCost[r.Cost] = r
+	}
+
+	var outside []int
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', tabwriter.TabIndent)
+	fmt.Fprintln(w, "Cost\tPassword 1 Mean\tPassword 2 Mean\tDifference\tCombined StdDev\t\t")
+	fmt.Fprintln(w, "----\t---------------\t---------------\t----------\t---------------\t\t")
+	for _, a := range first {
+		b, ok := secondByCost[a.Cost]
+		if !ok {
+			continue
+		}
+
+		spread := time.Duration(math.Hypot(float64(a.StdDev), float64(b.StdDev)))
+		diff := b.Mean - a.Mean
+		flag := ""
+		if diff.Abs() > spread {
+			flag = "!"
+			outside = append(outside, a.Cost)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%+.1f%%\t%s\t%s\t\n",
+			a.Cost, formatDuration(a.Mean), formatDuration(b.Mean),
+			float64(diff)/float64(a.Mean)*100, formatDuration(spread), flag)
+	}
+	w.Flush()
+
+	fmt.Fprintln(out)
+	if len(outside) == 0 {
+		fmt.Fprintln(out, "  Both passwords take the same time within noise at every cost")
+		return
+	}
+	fmt.Fprintf(out, "  Warning: the means differ by more than their combined stddev at cost %s;\n", joinInts(outside))
+	fmt.Fprintln(out, "  the work is fixed by the cost, so this usually means a noisy measurement")
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/eldad/bcryptbenchmark/bench"
+)
+
+// Layout of the -plot chart, in SVG user units.
+const (
+	plotWidth       = 720
+	plotHeight      = 420
+	plotMarginLeft  = 90
+	plotMarginRight = 30
+	plotMarginTop   = 50
+	plotMarginBelow = 60
+)
+
+type plotSeries struct {
+	Name    string
+	Color   string
+	Results []bench.CostResult
+}
+
+func writePlotFile(path string, report Report) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := writePlot(f, report); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writePlot renders the mean latency per cost as an SVG line chart, with
+// error bars one standard deviation either side of each mean. With
+// -plot-log the y-axis is logarithmic, which turns the doubling per cost
+// into a straight line.
+func writePlot(w io.Writer, report Report) error {
+	cfg := report.Config
+
+	var series []plotSeries
+	switch cfg.Mode {
+	case modeCompare:
+		series = append(series, plotSeries{"verify", "#d62728", report.Results})
+	case modeBoth:
+		series = append(series, plotSeries{"hash", "#1f77b4", report.Results})
+		series = append(series, plotSeries{"verify", "#d62728", report.VerifyResults})
+	default:
+		series = append(series, plotSeries{"hash", "#1f77b4", report.Results})
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="12">`+"\n",
+		plotWidth, plotHeight, plotWidth, plotHeight)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="white"/>`+"\n", plotWidth, plotHeight)
+	fmt.Fprintf(&b, `<text x="%d" y="25" text-anchor="middle" font-size="16">%s mean latency by cost</text>`+"\n",
+		plotWidth/2, html.EscapeString(cfg.Algorithm))
+
+	minCost, maxCost := math.MaxInt, math.MinInt
+	var lo, hi float64 = math.Inf(1), 0
+	for _, s := range series {
+		for _, r := range s.Results {
+			minCost, maxCost = min(minCost, r.Cost), max(maxCost, r.Cost)
+			lo = min(lo, float64(r.Mean-r.StdDev), float64(r.Mean))
+			hi = max(hi, float64(r.Mean+r.StdDev))
+		}
+	}
+	if maxCost < minCost {
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="middle">No cost level completed</text>`+"\n", plotWidth/2, plotHeight/2)
+		b.WriteString("</svg>\n")
+		_, err := w.Write(b.Bytes())
+		return err
+	}
+	if minCost == maxCost {
+		minCost, maxCost = minCost-1, maxCost+1
+	}
+
+	left, right := float64(plotMarginLeft), float64(plotWidth-plotMarginRight)
+	top, bottom := float64(plotMarginTop), float64(plotHeight-plotMarginBelow)
+	x := func(cost int) float64 {
+		return left + (right-left)*float64(cost-minCost)/float64(maxCost-minCost)
+	}
+
+	var y func(ns float64) float64
+	var ticks []float64
+	if cfg.PlotLog {
+		// Whole decades, so every tick is a power of ten.
+		lo = math.Pow(10, math.Floor(math.Log10(max(lo, 1))))
+		hi = math.Pow(10, math.Ceil(math.Log10(max(hi, lo*10))))
+		y = func(ns float64) float64 {
+			ns = max(ns, lo)
+			return bottom - (bottom-top)*math.Log10(ns/lo)/math.Log10(hi/lo)
+		}
+		for t := lo; t <= hi*1.0001; t *= 10 {
+			ticks = append(ticks, t)
+		}
+	} else {
+		step := niceStep(hi / 5)
+		hi = math.Ceil(hi/step) * step
+		y = func(ns float64) float64 {
+			return bottom - (bottom-top)*max(ns, 0)/hi
+		}
+		for t := 0.0; t <= hi*1.0001; t += step {
+			ticks = append(ticks, t)
+		}
+	}
+
+	// Gridlines and axes.
+	for _, t := range ticks {
+		label := "0"
+		if t > 0 {
+			label = formatDuration(time.Duration(t))
+		}
+		fmt.Fprintf(&b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#e0e0e0"/>`+"\n", left, y(t), right, y(t))
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" text-anchor="end" dominant-baseline="middle">%s</text>`+"\n",
+			left-8, y(t), html.EscapeString(label))
+	}
+	for cost := minCost; cost <= maxCost; cost++ {
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" text-anchor="middle">%d</text>`+"\n", x(cost), bottom+20, cost)
+	}
+	fmt.Fprintf(&b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="black"/>`+"\n", left, bottom, right, bottom)
+	fmt.Fprintf(&b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="black"/>`+"\n", left, top, left, bottom)
+	fmt.Fprintf(&b, `<text x="%.1f" y="%d" text-anchor="middle">Cost</text>`+"\n", (left+right)/2, plotHeight-15)
+	yLabel := "Mean latency"
+	if cfg.PlotLog {
+		yLabel += " (log scale)"
+	}
+	fmt.Fprintf(&b, `<text x="20" y="%.1f" text-anchor="middle" transform="rotate(-90 20 %.1f)">%s</text>`+"\n",
+		(top+bottom)/2, (top+bottom)/2, yLabel)
+
+	for i, s := range series {
+		// -costs can visit the costs in any order; the line runs left to
+		// right.
+		sorted := slices.Clone(s.Results)
+		slices.SortFunc(sorted, func(a, b bench.CostResult) int { return cmp.Compare(a.Cost, b.Cost) })
+
+		points := ""
+		for _, r := range sorted {
+			points += fmt.Sprintf("%.1f,%.1f ", x(r.Cost), y(float64(r.Mean)))
+		}
+		fmt.Fprintf(&b, `<polyline points="%s" fill="none" stroke="%s" stroke-width="2"/>`+"\n", points, s.Color)
+
+		for _, r := range s.Results {
+			px, py := x(r.Cost), y(float64(r.Mean))
+			upper, lower := y(float64(r.Mean+r.StdDev)), y(float64(r.Mean-r.StdDev))
+			fmt.Fprintf(&b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s"/>`+"\n", px, upper, px, lower, s.Color)
+			fmt.Fprintf(&b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s"/>`+"\n", px-4, upper, px+4, upper, s.Color)
+			fmt.Fprintf(&b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s"/>`+"\n", px-4, lower, px+4, lower, s.Color)
+			fmt.Fprintf(&b, `<circle cx="%.1f" cy="%.1f" r="3.5" fill="%s"/>`+"\n", px, py, s.Color)
+			fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" text-anchor="middle" font-size="10">%s</text>`+"\n",
+				px, upper-6, html.EscapeString(formatDuration(r.Mean)))
+		}
+
+		if len(series) > 1 {
+			ly := top + float64(i)*18
+			fmt.Fprintf(&b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="2"/>`+"\n", left+15, ly, left+35, ly, s.Color)
+			fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" dominant-baseline="middle">%s</text>`+"\n", left+40, ly, s.Name)
+		}
+	}
+
+	b.WriteString("</svg>\n")
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// niceStep rounds a tick spacing up to 1, 2 or 5 times a power of ten.
+func niceStep(raw float64) float64 {
+	if raw <= 0 {
+		return 1
+	}
+	magnitude := math.Pow(10, math.Floor(math.Log10(raw)))
+	for _, m := range []float64{1, 2, 5} {
+		if raw <= m*magnitude {
+			return m * magnitude
+		}
+	}
+	return 10 * magnitude
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/eldad/bcryptbenchmark/bench"
+)
+
+type CloudCost struct {
+	Cost            int
+	Median          time.Duration
+	HashesPerSecond float64
+	CostPerMillion  float64
+	HashesPerDollar float64
+}
+
+// estimateCloudCost scales the throughput measured at cost, with busyCPUs
+// hashing at once, to vcpus: full utilization with linear scaling.
+func estimateCloudCost(r bench.CostResult, busyCPUs int, hourly float64, vcpus int) CloudCost {
+	perSecond := r.Throughput / float64(busyCPUs) * float64(vcpus)
+	perHour := perSecond * 3600
+
+	return CloudCost{
+		Cost:            r.Cost,
+		Median:          r.P50,
+		HashesPerSecond: perSecond,
+		CostPerMillion:  hourly / perHour * 1e6,
+		HashesPerDollar: perHour / hourly,
+	}
+}
+
+func writeCloudCost(out io.Writer, report Report, results []bench.CostResult, rec Recommendation) {
+	cfg := report.Config
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Cloud Cost")
+	fmt.Fprintln(out, "----------")
+
+	if rec.Cost == 0 {
+		fmt.Fprintln(out, "  No recommended cost; cannot estimate hashing cost")
+		return
+	}
+	if !rec.Measured {
+		fmt.Fprintln(out, "  Recommended cost was not benchmarked; cannot estimate hashing cost")
+		return
+	}
+
+	i := slices.IndexFunc(results, func(r bench.CostResult) bool { return r.Cost == rec.Cost })
+	// The benchmark keeps at most GOMAXPROCS of its -concurrency hashes
+	// running at a time.
+	busy := max(min(cfg.Concurrency, report.Environment.GOMAXPROCS), 1)
+	cc := estimateCloudCost(results[i], busy, cfg.InstanceCost, cfg.InstanceVCPUs)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "  Instance:\t$%.4f/hour, %d vCPUs\n", cfg.InstanceCost, cfg.InstanceVCPUs)
+	fmt.Fprintf(w, "  At Cost:\t%d (median %s)\n", cc.Cost, formatDuration(cc.Median))
+	fmt.Fprintf(w, "  Throughput:\t%.2f hashes/s\n", cc.HashesPerSecond)
+	fmt.Fprintf(w, "  Cost per Million Hashes:\t$%.4f\n", cc.CostPerMillion)
+	fmt.Fprintf(w, "  Hashes per Dollar:\t%.0f\n", cc.HashesPerDollar)
+	w.Flush()
+
+	fmt.Fprintf(out, "  Assumes full utilization of every vCPU at the measured throughput per busy CPU (%d busy).\n", busy)
+}
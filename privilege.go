@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+const (
+	privilegeRoot    = "root"
+	privilegeUser    = "user"
+	privilegeUnknown = "unknown"
+)
+
+func detectPrivilege() string {
+	switch uid := os.Geteuid(); uid {
+	case -1:
+		// Windows and Plan 9 have no effective user ID to inspect.
+		return privilegeUnknown
+	case 0:
+		return privilegeRoot
+	default:
+		return privilegeUser
+	}
+}
+
+func warnIfRoot(privilege string, allowRoot bool) {
+	if privilege != privilegeRoot || allowRoot {
+		return
+	}
+	fmt.Fprintln(os.Stderr, "Warning: running as root can change scheduling priority and skew results.")
+	fmt.Fprintln(os.Stderr, "         Run as an unprivileged user for representative numbers, or pass -allow-root to silence this.")
+}
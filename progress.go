@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/eldad/bcryptbenchmark/bench"
+)
+
+const (
+	progressAuto     = "auto"
+	progressAnimated = "animated"
+	progressPlain    = "plain"
+)
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+type spinner struct {
+	out io.Writer
+	cfg Config
+	idx int
+	// plain replaces the animated line with one plain line per completed
+	// cost, for logs and other outputs that are not a terminal.
+	plain bool
+	last  bench.Progress
+}
+
+// update redraws the progress line. Each redraw clears to the end of the
+// line first, so a shorter line leaves no stale characters behind.
+func (s *spinner) update(p bench.Progress) {
+	s.last = p
+	if s.plain {
+		return
+	}
+
+	eta := formatRemaining(p)
+	sweep := s.sweepProgress(p)
+
+	switch p.Phase {
+	case bench.PhasePrepare:
+		fmt.Fprintf(s.out, "\r\033[K%s %s Preparing: cost=%d, generating hash to verify, %s",
+			spinnerFrames[s.idx], sweep, p.Cost, eta)
+		return
+	case bench.PhaseWarmup:
+		s.idx = (s.idx + 1) % len(spinnerFrames)
+		fmt.Fprintf(s.out, "\r\033[K%s %s Warming up: cost=%d, warmup=%d/%d, %s",
+			spinnerFrames[s.idx], sweep, p.Cost, p.Iteration, s.cfg.Warmup, eta)
+	case bench.PhaseHash:
+		s.idx = (s.idx + 1) % len(spinnerFrames)
+		fmt.Fprintf(s.out, "\r\033[K%s %s Running: cost=%d, %s, %s",
+			spinnerFrames[s.idx], sweep, p.Cost, iterationProgress(s.cfg, p.Iteration, p.Measured), eta)
+	case bench.PhaseVerify:
+		s.idx = (s.idx + 1) % len(spinnerFrames)
+		fmt.Fprintf(s.out, "\r\033[K%s %s Verifying: cost=%d, %s, %s",
+			spinnerFrames[s.idx], sweep, p.Cost, iterationProgress(s.cfg, p.Iteration, p.Measured), eta)
+	}
+}
+
+// visited prints a line for a completed visit, after clearing the progress
+// line so it starts on a clean line. With -verbose the raw samples follow,
+// one per line.
+func (s *spinner) visited(v bench.Visit) {
+	s.clear()
+
+	what := "Cost"
+	if v.Phase == bench.PhaseVerify && s.cfg.Mode == modeBoth {
+		what = "Verify cost"
+	}
+	line := fmt.Sprintf("%s %s %d: %d samples, mean %s",
+		s.sweepProgress(bench.Progress{Pass: v.Pass, Cost: v.Cost}), what, v.Cost,
+		len(v.Durations), formatDuration(bench.CalculateMean(v.Durations)))
+	if s.plain && s.last.Estimated {
+		line += ", " + formatRemaining(s.last)
+	}
+	fmt.Fprintln(s.out, line)
+
+	if s.cfg.Verbose {
+		for _, d := range v.Durations {
+			fmt.Fprintf(s.out, "    %s\n", formatDuration(d))
+		}
+	}
+}
+
+// sweepProgress places cost within the sweep, e.g. "[4/7 costs]", adding
+// the step when it is not 1 and the pass when the sweep repeats.
+func (s *spinner) sweepProgress(p bench.Progress) string {
+	costs := s.cfg.Costs()
+	parts := []string{fmt.Sprintf("%d/%d costs", slices.Index(costs, p.Cost)+1, len(costs))}
+	if s.cfg.Step > 1 {
+		parts = append(parts, fmt.Sprintf("step %d", s.cfg.Step))
+	}
+	if s.cfg.Repeat > 1 {
+		parts = append(parts, fmt.Sprintf("pass %d/%d", p.Pass, s.cfg.Repeat))
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+func (s *spinner) clear() {
+	if !s.plain {
+		fmt.Fprint(s.out, "\r\033[K")
+	}
+}
+
+func iterationProgress(cfg Config, iter int, elapsed time.Duration) string {
+	if cfg.Budget > 0 {
+		return fmt.Sprintf("iteration=%d, %s/%s budget", iter, formatDuration(elapsed), formatDuration(cfg.Budget))
+	}
+	if cfg.AutoCV > 0 {
+		return fmt.Sprintf("iteration=%d, auto up to %d", iter, cfg.AutoMax)
+	}
+	return fmt.Sprintf("iteration=%d/%d", iter, cfg.Iterations)
+}
+
+func formatRemaining(p bench.Progress) string {
+	if !p.Estimated {
+		return "estimating..."
+	}
+	if p.Remaining < time.Second {
+		return "<1s remaining"
+	}
+	return fmt.Sprintf("~%s remaining", p.Remaining.Round(time.Second))
+}
+
+// animatedOnly silences the single-line progress of knee and encodings mode,
+// which has no plain form.
+func animatedOnly(progress io.Writer, plain bool) io.Writer {
+	if plain {
+		return io.Discard
+	}
+	return progress
+}
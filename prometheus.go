@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/eldad/bcryptbenchmark/bench"
+)
+
+// writePrometheus renders the results in the Prometheus text exposition
+// format, with metric names prefixed by the algorithm, e.g.
+// bcrypt_hash_duration_seconds{cost="12",quantile="0.95"}. Every series is a
+// gauge without a timestamp, so the output can be pushed to a Pushgateway
+// as is.
+func writePrometheus(w io.Writer, report Report) error {
+	cfg := report.Config
+
+	var b bytes.Buffer
+	for _, op := range report.operations() {
+		writePrometheusFamily(&b, cfg.Algorithm, op.Operation, op.Results)
+	}
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+func writePrometheusFamily(b *bytes.Buffer, algorithm, operation string, results []bench.CostResult) {
+	prefix := algorithm + "_" + operation
+	seconds := func(d time.Duration) string {
+		return strconv.FormatFloat(d.Seconds(), 'g', -1, 64)
+	}
+
+	gauge := func(name, help string) {
+		fmt.Fprintf(b, "# HELP %s %s\n", name, help)
+		fmt.Fprintf(b, "# TYPE %s gauge\n", name)
+	}
+
+	name := prefix + "_duration_seconds"
+	gauge(name, fmt.Sprintf("%s %s latency quantiles per cost.", algorithm, operation))
+	for _, r := range results {
+		// Quantiles 0 and 1 are the fastest and slowest sample, so a
+		// requested P0 or P100 is not repeated.
+		quantile := func(q float64, d time.Duration) {
+			label := strconv.FormatFloat(q, 'g', 12, 64)
+			fmt.Fprintf(b, "%s{cost=\"%d\",quantile=\"%s\"} %s\n", name, r.Cost, label, seconds(d))
+		}
+		quantile(0, r.Min)
+		for _, p := range r.Percentiles {
+			if p.Percentile > 0 && p.Percentile < 100 {
+				quantile(p.Percentile/100, p.Value)
+			}
+		}
+		quantile(1, r.Max)
+	}
+
+	name = prefix + "_duration_mean_seconds"
+	gauge(name, fmt.Sprintf("Mean %s %s latency per cost.", algorithm, operation))
+	for _, r := range results {
+		fmt.Fprintf(b, "%s{cost=\"%d\"} %s\n", name, r.Cost, seconds(r.Mean))
+	}
+
+	name = prefix + "_duration_stddev_seconds"
+	gauge(name, fmt.Sprintf("Standard deviation of %s %s latency per cost.", algorithm, operation))
+	for _, r := range results {
+		fmt.Fprintf(b, "%s{cost=\"%d\"} %s\n", name, r.Cost, seconds(r.StdDev))
+	}
+
+	name = prefix + "_iterations"
+	gauge(name, fmt.Sprintf("Number of %s %s samples per cost.", algorithm, operation))
+	for _, r := range results {
+		fmt.Fprintf(b, "%s{cost=\"%d\"} %d\n", name, r.Cost, r.Iterations)
+	}
+}
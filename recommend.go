@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/eldad/bcryptbenchmark/bench"
+)
+
+const recommendationBudget = 250 * time.Millisecond
+
+type Recommendation struct {
+	Cost         int
+	Median       time.Duration
+	Measured     bool
+	Budget       time.Duration
+	OptimalCost  int
+	FloorApplied bool
+	OverBudget   bool
+}
+
+func recommendCost(results []bench.CostResult, budget time.Duration, floor int) Recommendation {
+	rec := Recommendation{Budget: budget}
+
+	for _, r := range results {
+		if r.P50 <= budget && r.Cost > rec.OptimalCost {
+			rec.OptimalCost = r.Cost
+			rec.Cost = r.Cost
+			rec.Median = r.P50
+			rec.Measured = true
+		}
+	}
+
+	if floor > 0 && rec.Cost < floor {
+		rec.Cost = floor
+		rec.FloorApplied = true
+		rec.Median = 0
+		rec.Measured = false
+
+		for _, r := range results {
+			if r.Cost == floor {
+				rec.Median = r.P50
+				rec.Measured = true
+			}
+			// Hashing time grows with cost, so a cheaper cost that already
+			// blows the budget means the floor does too.
+			if r.Cost <= floor && r.P50 > budget {
+				rec.OverBudget = true
+			}
+		}
+	}
+
+	return rec
+}
+
+func writeRecommendation(out io.Writer, rec Recommendation, floor int) {
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Recommendation")
+	fmt.Fprintln(out, "--------------")
+
+	if rec.Cost == 0 {
+		fmt.Fprintf(out, "  No benchmarked cost fits the %s latency budget\n", formatDuration(rec.Budget))
+		return
+	}
+
+	if rec.Measured {
+		fmt.Fprintf(out, "  Recommended cost: %d (median %s, budget %s)\n",
+			rec.Cost, formatDuration(rec.Median), formatDuration(rec.Budget))
+	} else {
+		fmt.Fprintf(out, "  Recommended cost: %d (not benchmarked, budget %s)\n",
+			rec.Cost, formatDuration(rec.Budget))
+	}
+
+	if rec.FloorApplied {
+		if rec.OptimalCost > 0 {
+			fmt.Fprintf(out, "  Security floor %d overrode the latency-optimal cost %d\n", floor, rec.OptimalCost)
+		} else {
+			fmt.Fprintf(out, "  Security floor %d applied; no benchmarked cost fits the budget\n", floor)
+		}
+	}
+	if rec.OverBudget {
+		fmt.Fprintf(out, "  Warning: floor cost %d exceeds the %s latency budget\n", floor, formatDuration(rec.Budget))
+	}
+}
+
+func writeTargetSearch(out io.Writer, cfg Config, results []bench.CostResult) {
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Target Search")
+	fmt.Fprintln(out, "-------------")
+
+	if len(results) == 0 {
+		fmt.Fprintln(out, "  No cost level completed")
+		return
+	}
+
+	rec := recommendCost(results, cfg.Target, 0)
+	fmt.Fprintf(out, "  Cost %d is closest to the %s target without exceeding it (median %s)\n",
+		rec.OptimalCost, formatDuration(cfg.Target), formatDuration(rec.Median))
+
+	// With -costs the sweep need not be ascending, so look for the cheapest
+	// cost over the target rather than taking the last one measured.
+	var exceeded *bench.CostResult
+	highest := 0
+	for i, r := range results {
+		if r.P50 > cfg.Target && (exceeded == nil || r.Cost < exceeded.Cost) {
+			exceeded = &results[i]
+		}
+		highest = max(highest, r.Cost)
+	}
+
+	if exceeded != nil {
+		fmt.Fprintf(out, "  Stopped after cost %d exceeded the target (median %s)\n", exceeded.Cost, formatDuration(exceeded.P50))
+	} else if highest == cfg.EndCost {
+		fmt.Fprintf(out, "  Every cost up to the end cost %d fits the target; raise -end to search further\n", cfg.EndCost)
+	}
+}
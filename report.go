@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"slices"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/eldad/bcryptbenchmark/bench"
+)
+
+const (
+	formatText       = "text"
+	formatJSON       = "json"
+	formatASCIITable = "ascii-table"
+	formatStable     = "stable"
+	formatGrafana    = "grafana"
+	formatNDJSON     = "ndjson-metrics"
+	formatMarkdown   = "markdown"
+	formatPrometheus = "prometheus"
+)
+
+type Report struct {
+	RunID          string             `json:"run_id"`
+	Timestamp      time.Time          `json:"timestamp"`
+	Host           string             `json:"host"`
+	Privilege      string             `json:"privilege"`
+	Implementation Implementation     `json:"implementation"`
+	Environment    Environment        `json:"environment"`
+	Config         Config             `json:"config"`
+	Results        []bench.CostResult `json:"results"`
+	VerifyResults  []bench.CostResult `json:"verify_results,omitempty"`
+	// Password2Results is the -password2 sweep, in the same mode as
+	// Results.
+	Password2Results []bench.CostResult `json:"password2_results,omitempty"`
+	Interrupted      bool               `json:"interrupted"`
+	Cutoffs          []Cutoff           `json:"cutoffs,omitempty"`
+}
+
+// Cutoff records a sweep that stopped at Cost because a hash there ran past
+// -hash-timeout; that cost and every higher one were skipped.
+type Cutoff struct {
+	Operation string `json:"operation"`
+	Cost      int    `json:"cost"`
+}
+
+func appendCutoff(cutoffs []Cutoff, cutoff *Cutoff) []Cutoff {
+	if cutoff == nil || slices.Contains(cutoffs, *cutoff) {
+		return cutoffs
+	}
+	return append(cutoffs, *cutoff)
+}
+
+// Environment describes the runtime the numbers were measured on, so that a
+// saved report can be interpreted on its own.
+type Environment struct {
+	GoVersion  string `json:"go_version"`
+	OS         string `json:"os"`
+	Arch       string `json:"arch"`
+	NumCPU     int    `json:"num_cpu"`
+	GOMAXPROCS int    `json:"gomaxprocs"`
+}
+
+func detectEnvironment() Environment {
+	return Environment{
+		GoVersion:  runtime.Version(),
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		NumCPU:     runtime.NumCPU(),
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+	}
+}
+
+func writeEnvironment(out io.Writer, report Report) {
+	env := report.Environment
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Go Version:\t%s\n", env.GoVersion)
+	fmt.Fprintf(w, "OS/Arch:\t%s/%s\n", env.OS, env.Arch)
+	fmt.Fprintf(w, "CPUs:\t%d\n", env.NumCPU)
+	if report.Config.MaxProcs > 0 {
+		fmt.Fprintf(w, "GOMAXPROCS:\t%d (set by -maxprocs)\n", env.GOMAXPROCS)
+	} else {
+		fmt.Fprintf(w, "GOMAXPROCS:\t%d (default)\n", env.GOMAXPROCS)
+	}
+	w.Flush()
+}
+
+type statistic struct {
+	Name  string
+	Value time.Duration
+}
+
+// costStatistics lists the statistics the machine formats export per cost,
+// with the percentiles of -percentiles between min and max.
+func costStatistics(r bench.CostResult) []statistic {
+	stats := []statistic{{"mean", r.Mean}, {"stddev", r.StdDev}, {"min", r.Min}}
+	for _, p := range r.Percentiles {
+		stats = append(stats, statistic{percentileName(p.Percentile), p.Value})
+	}
+	return append(stats, statistic{"max", r.Max})
+}
+
+// reportedPercentiles returns the percentiles every result carries.
+func reportedPercentiles(cfg Config) []float64 {
+	if len(cfg.Percentiles) > 0 {
+		return cfg.Percentiles
+	}
+	return bench.DefaultPercentiles
+}
+
+// percentileName names a percentile in the machine formats, e.g. p99 or
+// p99.9.
+func percentileName(p float64) string {
+	return "p" + strconv.FormatFloat(p, 'g', -1, 64)
+}
+
+// operationResults is one result set of a report with the operation it
+// timed: hash or verify.
+type operationResults struct {
+	Operation string
+	Results   []bench.CostResult
+}
+
+// operations lists the result sets -mode produced, hash before verify. In
+// compare mode Results holds the verify timings.
+func (report Report) operations() []operationResults {
+	switch report.Config.Mode {
+	case modeCompare:
+		return []operationResults{{"verify", report.Results}}
+	case modeBoth:
+		return []operationResults{{"hash", report.Results}, {"verify", report.VerifyResults}}
+	default:
+		return []operationResults{{"hash", report.Results}}
+	}
+}
+
+func isHumanFormat(format string) bool {
+	return format == formatText || format == formatASCIITable
+}
+
+func newReport(cfg Config, privilege string) (Report, error) {
+	started := time.Now().UTC()
+
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+
+	runID, err := newRunID(started, cfg.Seed)
+	if err != nil {
+		return Report{}, fmt.Errorf("Error generating run ID: %w", err)
+	}
+
+	return Report{
+		RunID:          runID,
+		Timestamp:      started,
+		Host:           host,
+		Privilege:      privilege,
+		Implementation: detectImplementation(cfg.Algorithm),
+		Environment:    detectEnvironment(),
+		Config:         cfg,
+	}, nil
+}
+
+// newRunID returns a sortable identifier shared by every output of a run so
+// downstream systems can join records coming from different sinks. With a
+// seed the ID leaves out the start time and is the same on every run.
+func newRunID(started time.Time, seed *int64) (string, error) {
+	suffix := make([]byte, 4)
+	if _, err := io.ReadFull(randomSource(seed, seedStreamRunID), suffix); err != nil {
+		return "", err
+	}
+	if seed != nil {
+		return fmt.Sprintf("seed%d-%s", *seed, hex.EncodeToString(suffix)), nil
+	}
+	return fmt.Sprintf("%s-%s", started.Format("20060102T150405Z"), hex.EncodeToString(suffix)), nil
+}
+
+func writeJSON(w io.Writer, report Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
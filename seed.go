@@ -0,0 +1,29 @@
+package main
+
+import (
+	cryptorand "crypto/rand"
+	"encoding/binary"
+	"io"
+	"math/rand/v2"
+)
+
+// Streams drawn from a -seed, kept apart so that generating a password does
+// not shift the run ID and vice versa.
+const (
+	seedStreamPassword = iota
+	seedStreamRunID
+)
+
+// randomSource returns crypto/rand unless a seed is given, in which case it
+// returns a ChaCha8 stream derived from the seed. The seeded stream is fully
+// predictable and only meant to make benchmarks reproducible.
+func randomSource(seed *int64, stream int) io.Reader {
+	if seed == nil {
+		return cryptorand.Reader
+	}
+
+	var key [32]byte
+	binary.LittleEndian.PutUint64(key[:8], uint64(*seed))
+	binary.LittleEndian.PutUint64(key[8:16], uint64(stream))
+	return rand.NewChaCha8(key)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/eldad/bcryptbenchmark/bench"
+)
+
+const stableFormatVersion = 5
+
+func stableFields(cfg Config) []string {
+	fields := []string{"operation", "cost", "iterations", "mean_us", "stddev_us", "min_us"}
+	for _, p := range reportedPercentiles(cfg) {
+		fields = append(fields, percentileName(p)+"_us")
+	}
+	return append(fields, "max_us")
+}
+
+// writeStable emits one space-separated record per line with no padding and
+// no run-specific values such as timestamps, so two runs diff line by line.
+// Durations are always whole microseconds.
+func writeStable(w io.Writer, report Report, passwordLength int) error {
+	cfg := report.Config
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "format stable %d\n", stableFormatVersion)
+	fmt.Fprintf(&b, "config algorithm %s start %d end %d iterations %d password_length %d\n",
+		cfg.Algorithm, cfg.StartCost, cfg.EndCost, cfg.Iterations, passwordLength)
+	fmt.Fprintf(&b, "fields %s\n", strings.Join(stableFields(cfg), " "))
+
+	for _, op := range report.operations() {
+		results := slices.Clone(op.Results)
+		slices.SortFunc(results, func(a, b bench.CostResult) int { return a.Cost - b.Cost })
+		for _, r := range results {
+			fmt.Fprintf(&b, "result %s %d %d", op.Operation, r.Cost, r.Iterations)
+			for _, stat := range costStatistics(r) {
+				fmt.Fprintf(&b, " %d", stat.Value.Microseconds())
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
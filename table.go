@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+
+	"github.com/eldad/bcryptbenchmark/bench"
+)
+
+type borderStyle struct {
+	horizontal, vertical               string
+	topLeft, topMid, topRight          string
+	midLeft, midMid, midRight          string
+	bottomLeft, bottomMid, bottomRight string
+}
+
+var (
+	boxBorders   = borderStyle{"─", "│", "┌", "┬", "┐", "├", "┼", "┤", "└", "┴", "┘"}
+	plainBorders = borderStyle{"-", "|", "+", "+", "+", "+", "+", "+", "+", "+", "+"}
+)
+
+func resultsTable(results []bench.CostResult) ([]string, [][]string) {
+	trimmed := slices.ContainsFunc(results, func(r bench.CostResult) bool { return r.Trimmed > 0 })
+	allocs := slices.ContainsFunc(results, func(r bench.CostResult) bool { return r.BytesPerOp > 0 })
+
+	header := []string{"Cost", "Iterations"}
+	if trimmed {
+		header = append(header, "Trimmed")
+	}
+	header = append(header, "Mean", "StdDev", "Min")
+	if len(results) > 0 {
+		for _, p := range results[0].Percentiles {
+			header = append(header, percentileLabel(p.Percentile))
+		}
+	}
+	header = append(header, "Max", "Throughput")
+	if allocs {
+		header = append(header, "B/op", "Allocs/op")
+	}
+	rows := make([][]string, 0, len(results))
+
+	for _, r := range results {
+		cost := fmt.Sprint(r.Cost)
+		if r.Cached {
+			cost += " (cached)"
+		}
+		row := []string{cost, fmt.Sprint(r.Iterations)}
+		if trimmed {
+			row = append(row, fmt.Sprint(r.Trimmed))
+		}
+		row = append(row,
+			formatMean(r),
+			formatDuration(r.StdDev),
+			formatDuration(r.Min),
+		)
+		for _, p := range r.Percentiles {
+			cell := formatDuration(p.Value)
+			if r.Iterations < bench.MinSamplesForPercentile(p.Percentile) {
+				cell += "*"
+			}
+			row = append(row, cell)
+		}
+		row = append(row,
+			formatDuration(r.Max),
+			fmt.Sprintf("%.2f/s", r.Throughput),
+		)
+		if allocs {
+			row = append(row, fmt.Sprint(r.BytesPerOp), fmt.Sprint(r.AllocsPerOp))
+		}
+		rows = append(rows, row)
+	}
+
+	return header, rows
+}
+
+// writePercentileNote explains the asterisk resultsTable puts on percentiles
+// estimated from too few samples. marker is how the asterisk is written at
+// the start of a line.
+func writePercentileNote(out io.Writer, marker string, results []bench.CostResult) {
+	if len(results) == 0 {
+		return
+	}
+	fewest := results[0].Iterations
+	for _, r := range results {
+		fewest = min(fewest, r.Iterations)
+	}
+
+	var needs []string
+	for _, p := range results[0].Percentiles {
+		if n := bench.MinSamplesForPercentile(p.Percentile); fewest < n {
+			needs = append(needs, fmt.Sprintf("%s needs %d", percentileLabel(p.Percentile), n))
+		}
+	}
+	if len(needs) == 0 {
+		return
+	}
+
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "%s Too few samples to estimate this percentile; the value is interpolated toward the min or max.\n", marker)
+	fmt.Fprintf(out, "  Samples required: %s.\n", strings.Join(needs, ", "))
+}
+
+// writeCachedNote explains the mark resultsTable puts on costs loaded from
+// the -cache directory.
+func writeCachedNote(out io.Writer, results []bench.CostResult) {
+	if !slices.ContainsFunc(results, func(r bench.CostResult) bool { return r.Cached }) {
+		return
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "(cached) Loaded from the -cache directory instead of measured in this run; -no-cache measures it again.")
+}
+
+// percentileLabel names a column, e.g. P99.9, with P50 called Median.
+func percentileLabel(p float64) string {
+	if p == 50 {
+		return "Median"
+	}
+	return "P" + strconv.FormatFloat(p, 'g', -1, 64)
+}
+
+// formatMean shows the mean with its 95% confidence margin, or bare when a
+// single sample gives no interval.
+func formatMean(r bench.CostResult) string {
+	if r.MeanMargin == 0 {
+		return formatDuration(r.Mean)
+	}
+	return formatDuration(r.Mean) + " ±" + formatDuration(r.MeanMargin)
+}
+
+func writeTabTable(out io.Writer, header []string, rows [][]string) {
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', tabwriter.TabIndent)
+
+	underlines := make([]string, len(header))
+	for i, h := range header {
+		underlines[i] = strings.Repeat("-", utf8.RuneCountInString(h))
+	}
+
+	fmt.Fprintln(w, strings.Join(header, "\t")+"\t")
+	fmt.Fprintln(w, strings.Join(underlines, "\t")+"\t")
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t")+"\t")
+	}
+	w.Flush()
+}
+
+func writeBorderedTable(out io.Writer, header []string, rows [][]string, style borderStyle) {
+	widths := make([]int, len(header))
+	for i, h := range header {
+		widths[i] = utf8.RuneCountInString(h)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+
+	rule := func(left, mid, right string) {
+		segments := make([]string, len(widths))
+		for i, width := range widths {
+			segments[i] = strings.Repeat(style.horizontal, width+2)
+		}
+		fmt.Fprintln(out, left+strings.Join(segments, mid)+right)
+	}
+	line := func(cells []string) {
+		padded := make([]string, len(cells))
+		for i, cell := range cells {
+			padded[i] = " " + cell + strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)) + " "
+		}
+		fmt.Fprintln(out, style.vertical+strings.Join(padded, style.vertical)+style.vertical)
+	}
+
+	rule(style.topLeft, style.topMid, style.topRight)
+	line(header)
+	rule(style.midLeft, style.midMid, style.midRight)
+	for _, row := range rows {
+		line(row)
+	}
+	rule(style.bottomLeft, style.bottomMid, style.bottomRight)
+}
+
+func writeMarkdownTable(out io.Writer, header []string, rows [][]string) {
+	align := make([]string, len(header))
+	for i := range align {
+		align[i] = "---:"
+	}
+
+	fmt.Fprintln(out, "| "+strings.Join(header, " | ")+" |")
+	fmt.Fprintln(out, "|"+strings.Join(align, "|")+"|")
+	for _, row := range rows {
+		fmt.Fprintln(out, "| "+strings.Join(row, " | ")+" |")
+	}
+}
+
+func asciiOnly(rows [][]string) [][]string {
+	out := make([][]string, len(rows))
+	for i, row := range rows {
+		out[i] = make([]string, len(row))
+		for j, cell := range row {
+			cell = strings.ReplaceAll(cell, "µs", "us")
+			out[i][j] = strings.ReplaceAll(cell, "±", "+/-")
+		}
+	}
+	return out
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/eldad/bcryptbenchmark/bench"
+)
+
+const (
+	modeGenerate = "generate"
+	modeCompare  = "compare"
+	modeBoth     = "both"
+)
+
+// verifyAsymmetryTolerance is how far the verify/hash ratio may drift from 1
+// before it is flagged. Both paths run the full key schedule, so they should
+// take nearly the same time.
+const verifyAsymmetryTolerance = 0.10
+
+func writeHashVerifyComparison(out io.Writer, hash, verify []bench.CostResult) {
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Hash vs Verify")
+	fmt.Fprintln(out, "--------------")
+	fmt.Fprintln(out)
+
+	verifyByCost := make(map[int]bench.CostResult, len(verify))
+	for _, v := range verify {
+		verifyByCost[v.Cost] = v
+	}
+
+	var asymmetric []int
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', tabwriter.TabIndent)
+	fmt.Fprintln(w, "Cost\tHash Mean\tVerify Mean\tVerify/Hash\t\t")
+	fmt.Fprintln(w, "----\t---------\t-----------\t-----------\t\t")
+	for _, h := range hash {
+		v, ok := verifyByCost[h.Cost]
+		if !ok {
+			continue
+		}
+
+		ratio := float64(v.Mean) / float64(h.Mean)
+		flag := ""
+		if ratio < 1-verifyAsymmetryTolerance || ratio > 1+verifyAsymmetryTolerance {
+			flag = "!"
+			asymmetric = append(asymmetric, h.Cost)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%.2fx\t%s\t\n",
+			h.Cost, formatDuration(h.Mean), formatDuration(v.Mean), ratio, flag)
+	}
+	w.Flush()
+
+	fmt.Fprintln(out)
+	if len(asymmetric) == 0 {
+		fmt.Fprintf(out, "  Verify and hash times agree within %.0f%% at every cost\n", verifyAsymmetryTolerance*100)
+		return
+	}
+	fmt.Fprintf(out, "  Warning: verify and hash times differ by more than %.0f%% at cost %s;\n",
+		verifyAsymmetryTolerance*100, joinInts(asymmetric))
+	fmt.Fprintln(out, "  both run the full key schedule, so this usually means a noisy measurement")
+}
+
+func joinInts(values []int) string {
+	s := ""
+	for i, v := range values {
+		if i > 0 {
+			s += ", "
+		}
+		s += fmt.Sprint(v)
+	}
+	return s
+}